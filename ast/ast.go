@@ -2,12 +2,14 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"strconv"
 	"ziplang/token"
 )
 
 type Program struct {
 	Statements []Statement
+	Comments   []token.Token // every COMMENT and DOC_COMMENT, in source order
 }
 
 func (p *Program) TokenValue() string {
@@ -18,6 +20,18 @@ func (p *Program) TokenValue() string {
 	}
 }
 
+// Span runs from the start of the first statement to the end of the last.
+func (p *Program) Span() Span {
+	if len(p.Statements) == 0 {
+		return Span{}
+	}
+
+	return Span{
+		Start: p.Statements[0].Span().Start,
+		End:   p.Statements[len(p.Statements)-1].Span().End,
+	}
+}
+
 func (p *Program) ToString() string {
 	var out bytes.Buffer
 
@@ -36,6 +50,14 @@ func (p *Program) ToString() string {
 type Node interface {
 	TokenValue() string
 	ToString() string
+	Span() Span
+}
+
+// Span is the extent of a node in the source, from its first byte to just
+// past its last, including delimiters such as closing brackets.
+type Span struct {
+	Start token.Position
+	End   token.Position
 }
 
 type Statement interface {
@@ -51,12 +73,17 @@ type Expression interface {
 type ExpressionStatement struct {
 	Token      token.Token
 	Expression Expression
+	Extent     Span
 }
 
 func (es *ExpressionStatement) TokenValue() string {
 	return es.Token.Value
 }
 
+func (es *ExpressionStatement) Span() Span {
+	return es.Extent
+}
+
 func (es *ExpressionStatement) ToString() string {
 	var out bytes.Buffer
 
@@ -74,14 +101,19 @@ func (es *ExpressionStatement) ToString() string {
 func (es *ExpressionStatement) StatementNode() {}
 
 type ReturnStatement struct {
-	Token token.Token
-	Value Expression
+	Token  token.Token
+	Value  Expression
+	Extent Span
 }
 
 func (rs *ReturnStatement) TokenValue() string {
 	return rs.Token.Value
 }
 
+func (rs *ReturnStatement) Span() Span {
+	return rs.Extent
+}
+
 func (rs *ReturnStatement) ToString() string {
 	var out bytes.Buffer
 
@@ -99,15 +131,21 @@ func (rs *ReturnStatement) ToString() string {
 func (rs *ReturnStatement) StatementNode() {}
 
 type IdentifierStatement struct {
-	Token token.Token
-	Type  token.Token // const (::) or var (:=) or reassign var (=)
-	Value Expression
+	Token  token.Token
+	Type   token.Token // const (::) or var (:=) or reassign var (= or a compound assignment such as +=)
+	Value  Expression
+	Doc    string // text of the /// comments directly above a :: declaration
+	Extent Span
 }
 
 func (is *IdentifierStatement) TokenValue() string {
 	return is.Token.Value
 }
 
+func (is *IdentifierStatement) Span() Span {
+	return is.Extent
+}
+
 func (is *IdentifierStatement) ToString() string {
 	var out bytes.Buffer
 
@@ -120,7 +158,13 @@ func (is *IdentifierStatement) ToString() string {
 	out.WriteString(",\n")
 	out.WriteString("Value: ")
 	out.WriteString(is.Value.ToString())
-	out.WriteString(",\n}")
+	out.WriteString(",\n")
+	if is.Doc != "" {
+		out.WriteString("Doc: ")
+		out.WriteString(is.Doc)
+		out.WriteString(",\n")
+	}
+	out.WriteString("}")
 
 	return out.String()
 }
@@ -128,14 +172,20 @@ func (is *IdentifierStatement) ToString() string {
 func (is *IdentifierStatement) StatementNode() {}
 
 type NumberExpression struct {
-	Token token.Token
-	Value int
+	Token  token.Token
+	Value  int
+	Big    *big.Int // set instead of Value for literals that do not fit in an int
+	Extent Span
 }
 
 func (ne *NumberExpression) TokenValue() string {
 	return ne.Token.Value
 }
 
+func (ne *NumberExpression) Span() Span {
+	return ne.Extent
+}
+
 func (ne *NumberExpression) ToString() string {
 	var out bytes.Buffer
 
@@ -144,7 +194,11 @@ func (ne *NumberExpression) ToString() string {
 	out.WriteString(ne.Token.ToString())
 	out.WriteString(",\n")
 	out.WriteString("Value: ")
-	out.WriteString(strconv.Itoa(ne.Value))
+	if ne.Big != nil {
+		out.WriteString(ne.Big.String())
+	} else {
+		out.WriteString(strconv.Itoa(ne.Value))
+	}
 	out.WriteString(",\n")
 	out.WriteString("}")
 
@@ -153,15 +207,51 @@ func (ne *NumberExpression) ToString() string {
 
 func (ne *NumberExpression) ExpressionNode() {}
 
+type FloatExpression struct {
+	Token  token.Token
+	Value  float64
+	Extent Span
+}
+
+func (fe *FloatExpression) TokenValue() string {
+	return fe.Token.Value
+}
+
+func (fe *FloatExpression) Span() Span {
+	return fe.Extent
+}
+
+func (fe *FloatExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("FloatExpression {\n")
+	out.WriteString("Token: ")
+	out.WriteString(fe.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Value: ")
+	out.WriteString(strconv.FormatFloat(fe.Value, 'g', -1, 64))
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (fe *FloatExpression) ExpressionNode() {}
+
 type IdentifierExpression struct {
-	Token token.Token
-	Value string
+	Token  token.Token
+	Value  string
+	Extent Span
 }
 
 func (ie *IdentifierExpression) TokenValue() string {
 	return ie.Token.Value
 }
 
+func (ie *IdentifierExpression) Span() Span {
+	return ie.Extent
+}
+
 func (ie *IdentifierExpression) ToString() string {
 	var out bytes.Buffer
 
@@ -180,14 +270,19 @@ func (ie *IdentifierExpression) ToString() string {
 func (ie *IdentifierExpression) ExpressionNode() {}
 
 type StringExpression struct {
-	Token token.Token
-	Value string
+	Token  token.Token
+	Value  string
+	Extent Span
 }
 
 func (se *StringExpression) TokenValue() string {
 	return se.Token.Value
 }
 
+func (se *StringExpression) Span() Span {
+	return se.Extent
+}
+
 func (se *StringExpression) ToString() string {
 	var out bytes.Buffer
 
@@ -205,15 +300,53 @@ func (se *StringExpression) ToString() string {
 
 func (se *StringExpression) ExpressionNode() {}
 
+type InterpolatedStringExpression struct {
+	Token  token.Token
+	Parts  []Expression // literal text as *StringExpression, in source order
+	Extent Span
+}
+
+func (ise *InterpolatedStringExpression) TokenValue() string {
+	return ise.Token.Value
+}
+
+func (ise *InterpolatedStringExpression) Span() Span {
+	return ise.Extent
+}
+
+func (ise *InterpolatedStringExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("InterpolatedStringExpression {\n")
+	out.WriteString("Token: ")
+	out.WriteString(ise.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Parts: ")
+	for _, p := range ise.Parts {
+		out.WriteString(p.ToString())
+		out.WriteString(",\n")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (ise *InterpolatedStringExpression) ExpressionNode() {}
+
 type BooleanExpression struct {
-	Token token.Token
-	Value bool
+	Token  token.Token
+	Value  bool
+	Extent Span
 }
 
 func (be *BooleanExpression) TokenValue() string {
 	return be.Token.Value
 }
 
+func (be *BooleanExpression) Span() Span {
+	return be.Extent
+}
+
 func (be *BooleanExpression) ToString() string {
 	var out bytes.Buffer
 
@@ -232,23 +365,24 @@ func (be *BooleanExpression) ToString() string {
 func (be *BooleanExpression) ExpressionNode() {}
 
 type InfixExpression struct {
-	Token    token.Token
 	Left     Expression
 	Operator token.Token
 	Right    Expression
+	Extent   Span
 }
 
 func (ie *InfixExpression) TokenValue() string {
-	return ie.Token.Value
+	return ie.Operator.Value
+}
+
+func (ie *InfixExpression) Span() Span {
+	return ie.Extent
 }
 
 func (ie *InfixExpression) ToString() string {
 	var out bytes.Buffer
 
 	out.WriteString("InfixExpression {\n")
-	out.WriteString("Token: ")
-	out.WriteString(ie.Token.ToString())
-	out.WriteString(",\n")
 	out.WriteString("Left: ")
 	out.WriteString(ie.Left.ToString())
 	out.WriteString(",\n")
@@ -265,16 +399,58 @@ func (ie *InfixExpression) ToString() string {
 
 func (ie *InfixExpression) ExpressionNode() {}
 
+// LogicalExpression is a short-circuiting &&, || or ??. It is kept apart
+// from InfixExpression because its right operand is evaluated conditionally.
+type LogicalExpression struct {
+	Left     Expression
+	Operator token.Token
+	Right    Expression
+	Extent   Span
+}
+
+func (le *LogicalExpression) TokenValue() string {
+	return le.Operator.Value
+}
+
+func (le *LogicalExpression) Span() Span {
+	return le.Extent
+}
+
+func (le *LogicalExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("LogicalExpression {\n")
+	out.WriteString("Left: ")
+	out.WriteString(le.Left.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Operator: ")
+	out.WriteString(le.Operator.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Right: ")
+	out.WriteString(le.Right.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (le *LogicalExpression) ExpressionNode() {}
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator token.Token
 	Right    Expression
+	Extent   Span
 }
 
 func (pe *PrefixExpression) TokenValue() string {
 	return pe.Token.Value
 }
 
+func (pe *PrefixExpression) Span() Span {
+	return pe.Extent
+}
+
 func (pe *PrefixExpression) ToString() string {
 	var out bytes.Buffer
 
@@ -298,12 +474,17 @@ func (pe *PrefixExpression) ExpressionNode() {}
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	Extent     Span
 }
 
 func (bs *BlockStatement) TokenValue() string {
 	return bs.Token.Value
 }
 
+func (bs *BlockStatement) Span() Span {
+	return bs.Extent
+}
+
 func (bs *BlockStatement) ToString() string {
 	var out bytes.Buffer
 
@@ -321,18 +502,23 @@ func (bs *BlockStatement) ToString() string {
 	return out.String()
 }
 
-func (bs *BlockStatement) statementNode() {}
+func (bs *BlockStatement) StatementNode() {}
 
 type FunctionExpression struct {
 	Token      token.Token
 	Parameters []*IdentifierExpression
 	Body       *BlockStatement
+	Extent     Span
 }
 
 func (fe *FunctionExpression) TokenValue() string {
 	return fe.Token.Value
 }
 
+func (fe *FunctionExpression) Span() Span {
+	return fe.Extent
+}
+
 func (fe *FunctionExpression) ToString() string {
 	var out bytes.Buffer
 
@@ -360,12 +546,17 @@ type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	Extent    Span
 }
 
 func (ce *CallExpression) TokenValue() string {
 	return ce.Token.Value
 }
 
+func (ce *CallExpression) Span() Span {
+	return ce.Extent
+}
+
 func (ce *CallExpression) ToString() string {
 	var out bytes.Buffer
 
@@ -387,3 +578,424 @@ func (ce *CallExpression) ToString() string {
 }
 
 func (ce *CallExpression) ExpressionNode() {}
+
+type IfExpression struct {
+	Token       token.Token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement // nil without else; holds the nested if for else if
+	Extent      Span
+}
+
+func (ie *IfExpression) TokenValue() string {
+	return ie.Token.Value
+}
+
+func (ie *IfExpression) Span() Span {
+	return ie.Extent
+}
+
+func (ie *IfExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("IfExpression {\n")
+	out.WriteString("Token: ")
+	out.WriteString(ie.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Condition: ")
+	out.WriteString(ie.Condition.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Consequence: ")
+	out.WriteString(ie.Consequence.ToString())
+	out.WriteString(",\n")
+	if ie.Alternative != nil {
+		out.WriteString("Alternative: ")
+		out.WriteString(ie.Alternative.ToString())
+		out.WriteString(",\n")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (ie *IfExpression) ExpressionNode() {}
+
+// ConditionalExpression is the ternary cond ? a : b. Only the chosen branch
+// is evaluated.
+type ConditionalExpression struct {
+	Token       token.Token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+	Extent      Span
+}
+
+func (ce *ConditionalExpression) TokenValue() string {
+	return ce.Token.Value
+}
+
+func (ce *ConditionalExpression) Span() Span {
+	return ce.Extent
+}
+
+func (ce *ConditionalExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("ConditionalExpression {\n")
+	out.WriteString("Token: ")
+	out.WriteString(ce.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Condition: ")
+	out.WriteString(ce.Condition.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Consequence: ")
+	out.WriteString(ce.Consequence.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Alternative: ")
+	out.WriteString(ce.Alternative.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (ce *ConditionalExpression) ExpressionNode() {}
+
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+	Extent    Span
+}
+
+func (ws *WhileStatement) TokenValue() string {
+	return ws.Token.Value
+}
+
+func (ws *WhileStatement) Span() Span {
+	return ws.Extent
+}
+
+func (ws *WhileStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("WhileStatement {\n")
+	out.WriteString("Token: ")
+	out.WriteString(ws.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Condition: ")
+	out.WriteString(ws.Condition.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Body: ")
+	out.WriteString(ws.Body.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (ws *WhileStatement) StatementNode() {}
+
+type ForStatement struct {
+	Token     token.Token
+	Init      Statement  // optional
+	Condition Expression // optional, loops forever when nil
+	Post      Statement  // optional
+	Body      *BlockStatement
+	Extent    Span
+}
+
+func (fs *ForStatement) TokenValue() string {
+	return fs.Token.Value
+}
+
+func (fs *ForStatement) Span() Span {
+	return fs.Extent
+}
+
+func (fs *ForStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("ForStatement {\n")
+	out.WriteString("Token: ")
+	out.WriteString(fs.Token.ToString())
+	out.WriteString(",\n")
+	if fs.Init != nil {
+		out.WriteString("Init: ")
+		out.WriteString(fs.Init.ToString())
+		out.WriteString(",\n")
+	}
+	if fs.Condition != nil {
+		out.WriteString("Condition: ")
+		out.WriteString(fs.Condition.ToString())
+		out.WriteString(",\n")
+	}
+	if fs.Post != nil {
+		out.WriteString("Post: ")
+		out.WriteString(fs.Post.ToString())
+		out.WriteString(",\n")
+	}
+	out.WriteString("Body: ")
+	out.WriteString(fs.Body.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (fs *ForStatement) StatementNode() {}
+
+type BreakStatement struct {
+	Token  token.Token
+	Extent Span
+}
+
+func (bs *BreakStatement) TokenValue() string {
+	return bs.Token.Value
+}
+
+func (bs *BreakStatement) Span() Span {
+	return bs.Extent
+}
+
+func (bs *BreakStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("BreakStatement {\n")
+	out.WriteString("Token: ")
+	out.WriteString(bs.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (bs *BreakStatement) StatementNode() {}
+
+type ContinueStatement struct {
+	Token  token.Token
+	Extent Span
+}
+
+func (cs *ContinueStatement) TokenValue() string {
+	return cs.Token.Value
+}
+
+func (cs *ContinueStatement) Span() Span {
+	return cs.Extent
+}
+
+func (cs *ContinueStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("ContinueStatement {\n")
+	out.WriteString("Token: ")
+	out.WriteString(cs.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (cs *ContinueStatement) StatementNode() {}
+
+type ArrayExpression struct {
+	Token    token.Token
+	Elements []Expression
+	Extent   Span
+}
+
+func (ae *ArrayExpression) TokenValue() string {
+	return ae.Token.Value
+}
+
+func (ae *ArrayExpression) Span() Span {
+	return ae.Extent
+}
+
+func (ae *ArrayExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("ArrayExpression {\n")
+	out.WriteString("Token: ")
+	out.WriteString(ae.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Elements: ")
+	for _, e := range ae.Elements {
+		out.WriteString(e.ToString())
+		out.WriteString(",\n")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (ae *ArrayExpression) ExpressionNode() {}
+
+type IndexExpression struct {
+	Token  token.Token
+	Left   Expression
+	Index  Expression
+	Extent Span
+}
+
+func (ie *IndexExpression) TokenValue() string {
+	return ie.Token.Value
+}
+
+func (ie *IndexExpression) Span() Span {
+	return ie.Extent
+}
+
+func (ie *IndexExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("IndexExpression {\n")
+	out.WriteString("Token: ")
+	out.WriteString(ie.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Left: ")
+	out.WriteString(ie.Left.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Index: ")
+	out.WriteString(ie.Index.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (ie *IndexExpression) ExpressionNode() {}
+
+type IndexAssignStatement struct {
+	Token  token.Token
+	Target *IndexExpression
+	Type   token.Token // reassign (= or a compound assignment such as +=)
+	Value  Expression
+	Extent Span
+}
+
+func (is *IndexAssignStatement) TokenValue() string {
+	return is.Token.Value
+}
+
+func (is *IndexAssignStatement) Span() Span {
+	return is.Extent
+}
+
+func (is *IndexAssignStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("IndexAssignStatement {\n")
+	out.WriteString("Token: ")
+	out.WriteString(is.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Target: ")
+	out.WriteString(is.Target.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Type: ")
+	out.WriteString(is.Type.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Value: ")
+	out.WriteString(is.Value.ToString())
+	out.WriteString(",\n}")
+
+	return out.String()
+}
+
+func (is *IndexAssignStatement) StatementNode() {}
+
+type HashExpression struct {
+	Token  token.Token
+	Keys   []Expression
+	Values []Expression // Values[i] belongs to Keys[i]
+	Extent Span
+}
+
+func (he *HashExpression) TokenValue() string {
+	return he.Token.Value
+}
+
+func (he *HashExpression) Span() Span {
+	return he.Extent
+}
+
+func (he *HashExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("HashExpression {\n")
+	out.WriteString("Token: ")
+	out.WriteString(he.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("Pairs: ")
+	for i, k := range he.Keys {
+		out.WriteString("Key: ")
+		out.WriteString(k.ToString())
+		out.WriteString(",\n")
+		out.WriteString("Value: ")
+		out.WriteString(he.Values[i].ToString())
+		out.WriteString(",\n")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (he *HashExpression) ExpressionNode() {}
+
+// BadExpression stands in for an expression that could not be parsed, so
+// that the AST never holds nil nodes. Token is where the expression began.
+type BadExpression struct {
+	Token  token.Token
+	Extent Span
+}
+
+func (be *BadExpression) TokenValue() string {
+	return be.Token.Value
+}
+
+func (be *BadExpression) Span() Span {
+	return be.Extent
+}
+
+func (be *BadExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("BadExpression {\n")
+	out.WriteString("Token: ")
+	out.WriteString(be.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (be *BadExpression) ExpressionNode() {}
+
+// BadStatement stands in for a statement that could not be parsed.
+type BadStatement struct {
+	Token  token.Token
+	Extent Span
+}
+
+func (bs *BadStatement) TokenValue() string {
+	return bs.Token.Value
+}
+
+func (bs *BadStatement) Span() Span {
+	return bs.Extent
+}
+
+func (bs *BadStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("BadStatement {\n")
+	out.WriteString("Token: ")
+	out.WriteString(bs.Token.ToString())
+	out.WriteString(",\n")
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (bs *BadStatement) StatementNode() {}
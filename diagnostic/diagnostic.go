@@ -0,0 +1,90 @@
+// Package diagnostic turns parse and runtime errors into reports that show
+// the offending source, for people (plain or ANSI-colored text) and for
+// tools (JSON).
+package diagnostic
+
+import (
+	"ziplang/ast"
+	"ziplang/object"
+	"ziplang/parser"
+	"ziplang/token"
+)
+
+// RuntimeError is the code of diagnostics made from evaluator errors that
+// have no code of their own.
+const RuntimeError = "runtime-error"
+
+// Diagnostic is a single problem in a script. Span is empty when the
+// location is unknown, in which case no source is shown.
+type Diagnostic struct {
+	Code    string
+	Message string
+	Span    ast.Span
+	Hints   []string
+}
+
+// FromParseError converts a parser error, adding hints for common mistakes.
+func FromParseError(err parser.ParseError) Diagnostic {
+	return Diagnostic{
+		Code:    string(err.Code),
+		Message: err.Message,
+		Span:    ast.Span{Start: err.Start, End: err.End},
+		Hints:   parseHints(err),
+	}
+}
+
+// FromParseErrors converts every error of a parse, keeping their order.
+func FromParseErrors(errs []parser.ParseError) []Diagnostic {
+	diagnostics := make([]Diagnostic, len(errs))
+
+	for i, err := range errs {
+		diagnostics[i] = FromParseError(err)
+	}
+
+	return diagnostics
+}
+
+// FromRuntimeError converts an error returned by the evaluator.
+func FromRuntimeError(err *object.Error) Diagnostic {
+	code := string(err.Code)
+	if code == "" {
+		code = RuntimeError
+	}
+
+	return Diagnostic{
+		Code:    code,
+		Message: err.Message,
+		Span:    err.Extent,
+		Hints:   runtimeHints(err),
+	}
+}
+
+var closers = map[token.TokenType]string{
+	token.RPAREN:   ")",
+	token.RBRACKET: "]",
+	token.RBRACE:   "}",
+}
+
+func parseHints(err parser.ParseError) []string {
+	switch {
+	case err.Code == parser.NoPrefix && err.Found == token.COLON:
+		return []string{"did you mean `:=`?"}
+	case err.Code == parser.UnexpectedToken && err.Expected == token.LBRACE && err.Found == token.ASSIGN:
+		return []string{"did you mean `==`?"}
+	case err.Code == parser.UnexpectedToken && closers[err.Expected] != "":
+		return []string{"add the missing `" + closers[err.Expected] + "`"}
+	}
+
+	return nil
+}
+
+func runtimeHints(err *object.Error) []string {
+	switch err.Code {
+	case object.NotDeclared:
+		return []string{"declare it first with `:=`"}
+	case object.ConstantAssignment:
+		return []string{"declare it with `:=` instead of `::` to allow assignment"}
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package diagnostic
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"ziplang/evaluator"
+	"ziplang/lexer"
+	"ziplang/object"
+	"ziplang/parser"
+)
+
+func parseDiagnostics(file string, input string) []Diagnostic {
+	p := parser.New(lexer.NewFile(file, input))
+	p.Parse()
+
+	return FromParseErrors(p.Errors())
+}
+
+func TestRenderPlain(t *testing.T) {
+	input := "x := 1;\ny := ;\nif x = 1 { x }"
+
+	expected := `error[no-prefix]: no prefix parse function for SEMICOLON found
+ --> main.zip:2:6
+  |
+2 | y := ;
+  |      ^
+
+error[unexpected-token]: expected next token to be: LBRACE, got ASSIGN instead
+ --> main.zip:3:6
+  |
+3 | if x = 1 { x }
+  |      ^
+  = hint: did you mean ` + "`==`?\n"
+
+	var out bytes.Buffer
+
+	if err := Render(&out, input, parseDiagnostics("main.zip", input), Plain); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != expected {
+		t.Errorf("wrong output.\ngot:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
+
+func TestRenderUnderline(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x : 1;", "1 | x : 1;\n  |   ^\n  = hint: did you mean `:=`?\n"},
+		{"\tx := [1, 2;", "1 | \tx := [1, 2;\n  | \t          ^\n  = hint: add the missing `]`\n"},
+		{"ö := 1 +", "1 | ö := 1 +\n  |         ^\n"},
+		{"x := 0x;", "1 | x := 0x;\n  |      ^^\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+
+		if err := Render(&out, test.input, parseDiagnostics("", test.input), Plain); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasSuffix(out.String(), test.expected) {
+			t.Errorf("%q: wrong output.\ngot:\n%s\nwant suffix:\n%s", test.input, out.String(), test.expected)
+		}
+	}
+}
+
+func TestRenderRuntimeError(t *testing.T) {
+	input := "x := 1;\ny = x + 1;\nz = 1;"
+
+	p := parser.New(lexer.New(input))
+	program := p.Parse()
+
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	evaluated := evaluator.Evaluate(program, object.NewEnvironment())
+
+	err, ok := evaluated.(*object.Error)
+
+	if !ok {
+		t.Fatalf("object.Object is not an Error. got=%T (%v)", evaluated, evaluated)
+	}
+
+	expected := `error[not-declared]: identifier not declared: y
+ --> 2:1
+  |
+2 | y = x + 1;
+  | ^^^^^^^^^^
+  = hint: declare it first with ` + "`:=`\n"
+
+	var out bytes.Buffer
+
+	if err := Render(&out, input, []Diagnostic{FromRuntimeError(err)}, Plain); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != expected {
+		t.Errorf("wrong output.\ngot:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
+
+func TestRenderWithoutLocation(t *testing.T) {
+	var out bytes.Buffer
+
+	diagnostic := FromRuntimeError(&object.Error{Message: "assertion failed"})
+
+	if err := Render(&out, "", []Diagnostic{diagnostic}, Plain); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != "error[runtime-error]: assertion failed\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestRenderANSI(t *testing.T) {
+	input := "y := ;"
+
+	var out bytes.Buffer
+
+	if err := Render(&out, input, parseDiagnostics("", input), ANSI); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, part := range []string{
+		"\x1b[1;31merror[no-prefix]: no prefix parse function for SEMICOLON found\x1b[0m\n",
+		"\x1b[1;34m1 |\x1b[0m y := ;\n",
+		"\x1b[1;31m^\x1b[0m\n",
+	} {
+		if !strings.Contains(out.String(), part) {
+			t.Errorf("output does not contain %q. got=%q", part, out.String())
+		}
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	input := "x := 1;\nf(x, 2;"
+
+	var out bytes.Buffer
+
+	if err := Render(&out, input, parseDiagnostics("main.zip", input), JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []map[string]interface{}
+
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+
+	if len(decoded) != 1 {
+		t.Fatalf("wrong number of diagnostics. got=%d", len(decoded))
+	}
+
+	expected := map[string]interface{}{
+		"code":    "unexpected-token",
+		"message": "expected next token to be: RPAREN, got SEMICOLON instead",
+		"file":    "main.zip",
+		"start":   map[string]interface{}{"line": 2.0, "column": 7.0, "offset": 14.0},
+		"end":     map[string]interface{}{"line": 2.0, "column": 8.0, "offset": 15.0},
+		"source":  "f(x, 2;",
+		"hints":   []interface{}{"add the missing `)`"},
+	}
+
+	got, _ := json.Marshal(decoded[0])
+	want, _ := json.Marshal(expected)
+
+	if string(got) != string(want) {
+		t.Errorf("wrong diagnostic.\ngot:  %s\nwant: %s", got, want)
+	}
+}
+
+func TestRenderJSONEmpty(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := Render(&out, "", nil, JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != "[]\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestRuntimeErrorCodes(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCode  string
+		expectedHints []string
+	}{
+		{"y = 1;", "not-declared", []string{"declare it first with `:=`"}},
+		{"y += 1;", "not-declared", []string{"declare it first with `:=`"}},
+		{"x :: 1; x = 2;", "constant-assignment", []string{"declare it with `:=` instead of `::` to allow assignment"}},
+		{"x :: 1; x += 2;", "constant-assignment", []string{"declare it with `:=` instead of `::` to allow assignment"}},
+		{"x := 1; x := 2;", "already-declared", nil},
+		{"1 / 0", RuntimeError, nil},
+		{"x := -true; x", RuntimeError, nil},
+	}
+
+	for _, test := range tests {
+		p := parser.New(lexer.New(test.input))
+		program := p.Parse()
+
+		evaluated := evaluator.Evaluate(program, object.NewEnvironment())
+
+		err, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("%q: object.Object is not an Error. got=%T (%v)", test.input, evaluated, evaluated)
+			continue
+		}
+
+		diagnostic := FromRuntimeError(err)
+
+		if diagnostic.Code != test.expectedCode {
+			t.Errorf("%q: wrong code. got=%q, want=%q", test.input, diagnostic.Code, test.expectedCode)
+		}
+
+		if strings.Join(diagnostic.Hints, "\n") != strings.Join(test.expectedHints, "\n") {
+			t.Errorf("%q: wrong hints. got=%q, want=%q", test.input, diagnostic.Hints, test.expectedHints)
+		}
+	}
+
+	// hints depend on the code only, not on the wording of the message
+	diagnostic := FromRuntimeError(&object.Error{Code: object.NotDeclared, Message: "no such variable"})
+
+	if len(diagnostic.Hints) != 1 {
+		t.Errorf("wrong hints. got=%q", diagnostic.Hints)
+	}
+}
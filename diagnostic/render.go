@@ -0,0 +1,187 @@
+package diagnostic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"ziplang/token"
+)
+
+// Format selects how Render prints diagnostics.
+type Format int
+
+const (
+	Plain Format = iota
+	ANSI
+	JSON
+)
+
+// style holds the escape sequences used to color each part of a report.
+// Plain text uses the zero style.
+type style struct {
+	err, gutter, hint, reset string
+}
+
+var styles = map[Format]style{
+	Plain: {},
+	ANSI: {
+		err:    "\x1b[1;31m",
+		gutter: "\x1b[1;34m",
+		hint:   "\x1b[1;36m",
+		reset:  "\x1b[0m",
+	},
+}
+
+// Render writes diagnostics about source to w. Text reports look like
+//
+//	error[no-prefix]: no prefix parse function for COLON found
+//	 --> main.zip:2:3
+//	  |
+//	2 | y : 2;
+//	  |   ^
+//	  = hint: did you mean `:=`?
+//
+// and are separated by blank lines. JSON output is a single array.
+func Render(w io.Writer, source string, diagnostics []Diagnostic, format Format) error {
+	lines := strings.Split(source, "\n")
+
+	if format == JSON {
+		return renderJSON(w, lines, diagnostics)
+	}
+
+	s, ok := styles[format]
+
+	if !ok {
+		return fmt.Errorf("unknown diagnostic format: %d", format)
+	}
+
+	var out bytes.Buffer
+
+	for i, diagnostic := range diagnostics {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+
+		renderText(&out, lines, diagnostic, s)
+	}
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func renderText(out *bytes.Buffer, lines []string, diagnostic Diagnostic, s style) {
+	fmt.Fprintf(out, "%serror[%s]: %s%s\n", s.err, diagnostic.Code, diagnostic.Message, s.reset)
+
+	start := diagnostic.Span.Start
+	pad := ""
+
+	if start.Line > 0 {
+		pad = strings.Repeat(" ", len(strconv.Itoa(start.Line)))
+		fmt.Fprintf(out, "%s%s-->%s %s\n", pad, s.gutter, s.reset, location(start))
+	}
+
+	if text, ok := sourceLine(lines, start.Line); ok {
+		fmt.Fprintf(out, "%s %s|%s\n", pad, s.gutter, s.reset)
+		fmt.Fprintf(out, "%s%d |%s %s\n", s.gutter, start.Line, s.reset, text)
+		fmt.Fprintf(out, "%s %s|%s %s\n", pad, s.gutter, s.reset, underline(text, diagnostic.Span.Start, diagnostic.Span.End, s))
+	}
+
+	for _, hint := range diagnostic.Hints {
+		fmt.Fprintf(out, "%s %s= hint:%s %s\n", pad, s.hint, s.reset, hint)
+	}
+}
+
+func location(pos token.Position) string {
+	if pos.File == "" {
+		return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", pos.File, pos.Line, pos.Column)
+}
+
+// sourceLine returns the 1-based line of the source, if there is one.
+func sourceLine(lines []string, line int) (string, bool) {
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(lines[line-1], "\r"), true
+}
+
+// underline returns the carets marking start to end below text. A span
+// running past the line is marked up to its end, and an empty one, such
+// as EOF, gets a single caret.
+func underline(text string, start, end token.Position, s style) string {
+	runes := []rune(text)
+	from := min(max(start.Column-1, 0), len(runes))
+	to := len(runes)
+
+	if end.Line == start.Line {
+		to = min(end.Column-1, len(runes))
+	}
+
+	width := max(to-from, 1)
+
+	// keep tabs so that the carets line up with the source above
+	var out strings.Builder
+
+	for _, r := range runes[:from] {
+		if r == '\t' {
+			out.WriteRune('\t')
+		} else {
+			out.WriteRune(' ')
+		}
+	}
+
+	out.WriteString(s.err)
+	out.WriteString(strings.Repeat("^", width))
+	out.WriteString(s.reset)
+
+	return out.String()
+}
+
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+type jsonDiagnostic struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	File    string        `json:"file,omitempty"`
+	Start   *jsonPosition `json:"start,omitempty"`
+	End     *jsonPosition `json:"end,omitempty"`
+	Source  string        `json:"source,omitempty"` // the line Start is on
+	Hints   []string      `json:"hints"`
+}
+
+func renderJSON(w io.Writer, lines []string, diagnostics []Diagnostic) error {
+	out := make([]jsonDiagnostic, len(diagnostics))
+
+	for i, diagnostic := range diagnostics {
+		out[i] = jsonDiagnostic{
+			Code:    diagnostic.Code,
+			Message: diagnostic.Message,
+			Hints:   diagnostic.Hints,
+		}
+
+		if out[i].Hints == nil {
+			out[i].Hints = []string{}
+		}
+
+		start, end := diagnostic.Span.Start, diagnostic.Span.End
+
+		if start.Line > 0 {
+			out[i].File = start.File
+			out[i].Start = &jsonPosition{Line: start.Line, Column: start.Column, Offset: start.Offset}
+			out[i].End = &jsonPosition{Line: end.Line, Column: end.Column, Offset: end.Offset}
+			out[i].Source, _ = sourceLine(lines, start.Line)
+		}
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
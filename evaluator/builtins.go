@@ -0,0 +1,419 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"ziplang/object"
+)
+
+var output io.Writer = os.Stdout
+
+var builtins = map[string]*object.Builtin{}
+
+func init() {
+	RegisterBuiltin("len", builtinLen)
+	RegisterBuiltin("print", builtinPrint)
+	RegisterBuiltin("println", builtinPrintln)
+	RegisterBuiltin("type", builtinType)
+	RegisterBuiltin("str", builtinStr)
+	RegisterBuiltin("int", builtinInt)
+	RegisterBuiltin("float", builtinFloat)
+	RegisterBuiltin("floor", builtinFloor)
+	RegisterBuiltin("ceil", builtinCeil)
+	RegisterBuiltin("round", builtinRound)
+	RegisterBuiltin("assert", builtinAssert)
+	RegisterBuiltin("push", builtinPush)
+	RegisterBuiltin("pop", builtinPop)
+	RegisterBuiltin("first", builtinFirst)
+	RegisterBuiltin("last", builtinLast)
+	RegisterBuiltin("rest", builtinRest)
+	RegisterBuiltin("keys", builtinKeys)
+	RegisterBuiltin("values", builtinValues)
+	RegisterBuiltin("has", builtinHas)
+	RegisterBuiltin("delete", builtinDelete)
+}
+
+// RegisterBuiltin makes fn callable from ziplang programs as name. Registering
+// an existing name replaces the previous builtin. Identifiers bound in the
+// environment take precedence over builtins.
+func RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	builtins[name] = &object.Builtin{Name: name, Fn: fn}
+}
+
+// SetOutput sets the writer used by print and println. The default is
+// os.Stdout.
+func SetOutput(w io.Writer) {
+	output = w
+}
+
+func builtinLen(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.String:
+		return &object.Number{Value: utf8.RuneCountInString(arg.Value)}
+	case *object.Array:
+		return &object.Number{Value: len(arg.Elements)}
+	case *object.Hash:
+		return &object.Number{Value: arg.Len()}
+	default:
+		return newError("argument to `len` not supported, got %s", args[0].Type())
+	}
+}
+
+func builtinPrint(args ...object.Object) object.Object {
+	fmt.Fprint(output, joinArguments(args))
+
+	return NULL
+}
+
+func builtinPrintln(args ...object.Object) object.Object {
+	fmt.Fprintln(output, joinArguments(args))
+
+	return NULL
+}
+
+func joinArguments(args []object.Object) string {
+	values := []string{}
+	for _, arg := range args {
+		values = append(values, arg.ToString())
+	}
+
+	return strings.Join(values, " ")
+}
+
+func builtinType(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	return &object.String{Value: string(args[0].Type())}
+}
+
+func builtinStr(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	if str, ok := args[0].(*object.String); ok {
+		return str
+	}
+
+	return &object.String{Value: args[0].ToString()}
+}
+
+func builtinInt(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Number:
+		return arg
+	case *object.Float:
+		return roundingBuiltin("int", math.Trunc, args)
+	case *object.Boolean:
+		if arg.Value {
+			return &object.Number{Value: 1}
+		}
+		return &object.Number{Value: 0}
+	case *object.String:
+		value, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 10)
+
+		if !ok {
+			return newError("could not convert %q to NUMBER", arg.Value)
+		}
+
+		return object.NewNumber(value)
+	default:
+		return newError("argument to `int` not supported, got %s", args[0].Type())
+	}
+}
+
+func builtinFloat(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Float:
+		return arg
+	case *object.Number:
+		if arg.IsBig() {
+			value, _ := new(big.Float).SetInt(arg.Big).Float64()
+			return &object.Float{Value: value}
+		}
+		return &object.Float{Value: float64(arg.Value)}
+	case *object.String:
+		value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+
+		if err != nil {
+			return newError("could not convert %q to FLOAT", arg.Value)
+		}
+
+		return &object.Float{Value: value}
+	default:
+		return newError("argument to `float` not supported, got %s", args[0].Type())
+	}
+}
+
+func builtinFloor(args ...object.Object) object.Object {
+	return roundingBuiltin("floor", math.Floor, args)
+}
+
+func builtinCeil(args ...object.Object) object.Object {
+	return roundingBuiltin("ceil", math.Ceil, args)
+}
+
+// round rounds half away from zero.
+func builtinRound(args ...object.Object) object.Object {
+	return roundingBuiltin("round", math.Round, args)
+}
+
+// roundingBuiltin applies round to a numeric argument and returns the result
+// as an integer. Integer arguments are returned unchanged.
+func roundingBuiltin(name string, round func(float64) float64, args []object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Number:
+		return arg
+	case *object.Float:
+		if math.IsInf(arg.Value, 0) || math.IsNaN(arg.Value) {
+			return newError("cannot convert %s to NUMBER", arg.ToString())
+		}
+
+		value, _ := big.NewFloat(round(arg.Value)).Int(nil)
+
+		return object.NewNumber(value)
+	default:
+		return newError("argument to `%s` must be NUMBER or FLOAT, got %s", name, args[0].Type())
+	}
+}
+
+func builtinAssert(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments: want=1 or 2, got=%d", len(args))
+	}
+
+	condition, ok := args[0].(*object.Boolean)
+
+	if !ok {
+		return newError("first argument to `assert` must be BOOLEAN, got %s", args[0].Type())
+	}
+
+	if condition.Value {
+		return NULL
+	}
+
+	if len(args) == 2 {
+		return newError("assertion failed: %s", args[1].ToString())
+	}
+
+	return newError("assertion failed")
+}
+
+// push appends its remaining arguments to the array in place and returns it.
+func builtinPush(args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return newError("wrong number of arguments: want at least 2, got=%d", len(args))
+	}
+
+	array, ok := args[0].(*object.Array)
+
+	if !ok {
+		return newError("first argument to `push` must be ARRAY, got %s", args[0].Type())
+	}
+
+	array.Elements = append(array.Elements, args[1:]...)
+
+	return array
+}
+
+// pop removes the last element of the array in place and returns it, or
+// null when the array is empty.
+func builtinPop(args ...object.Object) object.Object {
+	array, err := arrayArgument("pop", args)
+
+	if err != nil {
+		return err
+	}
+
+	length := len(array.Elements)
+
+	if length == 0 {
+		return NULL
+	}
+
+	last := array.Elements[length-1]
+	array.Elements = array.Elements[:length-1]
+
+	return last
+}
+
+func builtinFirst(args ...object.Object) object.Object {
+	array, err := arrayArgument("first", args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	return array.Elements[0]
+}
+
+func builtinLast(args ...object.Object) object.Object {
+	array, err := arrayArgument("last", args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(array.Elements) == 0 {
+		return NULL
+	}
+
+	return array.Elements[len(array.Elements)-1]
+}
+
+// rest returns a new array holding every element but the first, or null
+// when the array is empty.
+func builtinRest(args ...object.Object) object.Object {
+	array, err := arrayArgument("rest", args)
+
+	if err != nil {
+		return err
+	}
+
+	length := len(array.Elements)
+
+	if length == 0 {
+		return NULL
+	}
+
+	elements := make([]object.Object, length-1)
+	copy(elements, array.Elements[1:])
+
+	return &object.Array{Elements: elements}
+}
+
+func arrayArgument(name string, args []object.Object) (*object.Array, *object.Error) {
+	if len(args) != 1 {
+		return nil, newError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	array, ok := args[0].(*object.Array)
+
+	if !ok {
+		return nil, newError("argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+
+	return array, nil
+}
+
+// keys returns the keys of a hash in insertion order.
+func builtinKeys(args ...object.Object) object.Object {
+	hash, err := hashArgument("keys", args)
+
+	if err != nil {
+		return err
+	}
+
+	elements := []object.Object{}
+	for _, pair := range hash.Pairs() {
+		elements = append(elements, pair.Key)
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// values returns the values of a hash in insertion order.
+func builtinValues(args ...object.Object) object.Object {
+	hash, err := hashArgument("values", args)
+
+	if err != nil {
+		return err
+	}
+
+	elements := []object.Object{}
+	for _, pair := range hash.Pairs() {
+		elements = append(elements, pair.Value)
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func builtinHas(args ...object.Object) object.Object {
+	hash, key, err := hashKeyArguments("has", args)
+
+	if err != nil {
+		return err
+	}
+
+	_, ok := hash.Get(key)
+
+	return booleanObject(ok)
+}
+
+// delete removes a key from the hash in place and returns the removed value,
+// or null when the key was not present.
+func builtinDelete(args ...object.Object) object.Object {
+	hash, key, err := hashKeyArguments("delete", args)
+
+	if err != nil {
+		return err
+	}
+
+	if val, ok := hash.Delete(key); ok {
+		return val
+	}
+
+	return NULL
+}
+
+func hashArgument(name string, args []object.Object) (*object.Hash, *object.Error) {
+	if len(args) != 1 {
+		return nil, newError("wrong number of arguments: want=1, got=%d", len(args))
+	}
+
+	hash, ok := args[0].(*object.Hash)
+
+	if !ok {
+		return nil, newError("argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+
+	return hash, nil
+}
+
+func hashKeyArguments(name string, args []object.Object) (*object.Hash, object.Hashable, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments: want=2, got=%d", len(args))
+	}
+
+	hash, ok := args[0].(*object.Hash)
+
+	if !ok {
+		return nil, nil, newError("first argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+
+	key, ok := args[1].(object.Hashable)
+
+	if !ok {
+		return nil, nil, newError("unusable as hash key: %s", args[1].Type())
+	}
+
+	return hash, key, nil
+}
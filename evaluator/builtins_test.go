@@ -0,0 +1,168 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+	"ziplang/object"
+)
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("öra")`, 3},
+		{`len(str(1234))`, 4},
+		{`len(str(-1))`, 2},
+		{`len(1)`, "argument to `len` not supported, got NUMBER"},
+		{`len("a", "b")`, "wrong number of arguments: want=1, got=2"},
+		{`type(1)`, `NUMBER`},
+		{`type(str(1))`, `STRING`},
+		{`type(true)`, `BOOLEAN`},
+		{`type(fn() {})`, `FUNCTION`},
+		{`type(len)`, `BUILTIN`},
+		{`str(12)`, `12`},
+		{`str(false)`, `false`},
+		{`str(-3) + str(true)`, `-3true`},
+		{`int(5)`, 5},
+		{`int(true)`, 1},
+		{`int(false)`, 0},
+		{`int(str(42))`, 42},
+		{`int(str(-7))`, -7},
+		{`int(str(true))`, `could not convert "true" to NUMBER`},
+		{`int(" 12 ")`, 12},
+		{`int(fn() {})`, "argument to `int` not supported, got FUNCTION"},
+		{`assert(1 == 1)`, nil},
+		{`assert(1 == 2)`, "assertion failed"},
+		{`assert(1 == 2, str(false))`, "assertion failed: false"},
+		{`assert(1)`, "first argument to `assert` must be BOOLEAN, got NUMBER"},
+		{`assert()`, "wrong number of arguments: want=1 or 2, got=0"},
+		{`len :: fn(x) { 99 }; len(1)`, 99},
+		{`len([])`, 0},
+		{`len([1, 2, 3])`, 3},
+		{`a := [1]; push(a, 2, 3); len(a)`, 3},
+		{`a := [1]; push(a, 2)[1]`, 2},
+		{`push(1, 2)`, "first argument to `push` must be ARRAY, got NUMBER"},
+		{`push([])`, "wrong number of arguments: want at least 2, got=1"},
+		{`a := [1, 2]; pop(a) + len(a)`, 3},
+		{`pop([])`, nil},
+		{`first([7, 8])`, 7},
+		{`first([])`, nil},
+		{`last([7, 8])`, 8},
+		{`last([])`, nil},
+		{`len(rest([7, 8, 9]))`, 2},
+		{`rest([7, 8, 9])[0]`, 8},
+		{`a := [7, 8]; b := rest(a); b[0] = 1; a[1]`, 8},
+		{`rest([])`, nil},
+		{`first(1)`, "argument to `first` must be ARRAY, got NUMBER"},
+		{`last([1], [2])`, "wrong number of arguments: want=1, got=2"},
+		{`len({})`, 0},
+		{`len({1: 1, 2: 2})`, 2},
+		{`type({})`, `HASH`},
+		{`str(keys({3: 1, 1: 2, 2: 3}))`, `[3, 1, 2]`},
+		{`str(values({3: 1, 1: 2, 2: 3}))`, `[1, 2, 3]`},
+		{`len(keys({}))`, 0},
+		{`has({1: 2}, 1)`, true},
+		{`has({1: 2}, 2)`, false},
+		{`has({1: 2}, [1])`, "unusable as hash key: ARRAY"},
+		{`h := {1: 10, 2: 20}; delete(h, 1) + len(h)`, 11},
+		{`delete({}, 1)`, nil},
+		{`keys([])`, "argument to `keys` must be HASH, got ARRAY"},
+		{`has([], 1)`, "first argument to `has` must be HASH, got ARRAY"},
+		{`delete({})`, "wrong number of arguments: want=2, got=1"},
+		{`str(float(2))`, `2.0`},
+		{`str(float(str(2.5)))`, `2.5`},
+		{`str(1.5e300 * 1e10)`, `+Inf`},
+		{`str(float(str(true)))`, `could not convert "true" to FLOAT`},
+		{`type(1.5)`, `FLOAT`},
+		{`int(2.9)`, 2},
+		{`int(-2.9)`, -2},
+		{`floor(2.5)`, 2},
+		{`floor(-2.5)`, -3},
+		{`ceil(2.1)`, 3},
+		{`ceil(-2.1)`, -2},
+		{`round(2.5)`, 3},
+		{`round(-2.5)`, -3},
+		{`round(2.4)`, 2},
+		{`round(7)`, 7},
+		{`floor(1e300 * 1e300)`, "cannot convert +Inf to NUMBER"},
+		{`ceil(true)`, "argument to `ceil` must be NUMBER or FLOAT, got BOOLEAN"},
+		{`str(int(str(99999999999999999999)))`, `99999999999999999999`},
+		{`str(floor(1e20))`, `100000000000000000000`},
+		{`str(float(99999999999999999999))`, `1e+20`},
+		{`len(str(2 * 9223372036854775807))`, 20},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestBuiltinPrint(t *testing.T) {
+	var out bytes.Buffer
+	previous := output
+	SetOutput(&out)
+	defer SetOutput(previous)
+
+	testEvaluate(`print(str(0), 1); print(true); println(); println(len, -2, fn(a) {});`)
+
+	expected := "0 1true\nbuiltin len -2 fn(a)\n"
+	if out.String() != expected {
+		t.Errorf("wrong output. got=%q, want=%q", out.String(), expected)
+	}
+}
+
+func TestBuiltinPrintReturnInArgument(t *testing.T) {
+	var out bytes.Buffer
+	previous := output
+	SetOutput(&out)
+	defer SetOutput(previous)
+
+	evaluated := testEvaluate(`f :: fn(x) { println(if x { return 7 }); 0 }; f(true)`)
+
+	if result, ok := evaluated.(*object.Number); !ok || result.Value != 7 {
+		t.Errorf("wrong result. got=%T (%v), want=7", evaluated, evaluated)
+	}
+
+	if out.String() != "" {
+		t.Errorf("println must not run. got output %q", out.String())
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	RegisterBuiltin("double", func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments: want=1, got=%d", len(args))
+		}
+
+		number, ok := args[0].(*object.Number)
+		if !ok {
+			return newError("argument to `double` must be NUMBER, got %s", args[0].Type())
+		}
+
+		return &object.Number{Value: number.Value * 2}
+	})
+	defer delete(builtins, "double")
+
+	evaluated := testEvaluate("double(21);")
+
+	result, ok := evaluated.(*object.Number)
+
+	if !ok {
+		t.Fatalf("object.Object is not a Number. got=%T (%v)", evaluated, evaluated)
+	}
+
+	if result.Value != 42 {
+		t.Errorf("object has wrong value. got=%d, want=42", result.Value)
+	}
+
+	evaluated = testEvaluate(`double(true);`)
+
+	if !isError(evaluated) {
+		t.Errorf("expected error for misuse of registered builtin. got=%T (%v)", evaluated, evaluated)
+	}
+}
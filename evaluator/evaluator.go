@@ -1,12 +1,19 @@
 package evaluator
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
 	"ziplang/ast"
 	"ziplang/object"
 	"ziplang/token"
 )
 
+// maxNumberBits bounds the size of numbers built by << and **, so that a
+// single expression cannot exhaust the memory of the host program.
+const maxNumberBits = 1 << 22
 
 var (
   TRUE = &object.Boolean{
@@ -16,36 +23,152 @@ var (
     Value: false,
   }
   NULL = &object.Null{}
+  BREAK = &object.Break{}
+  CONTINUE = &object.Continue{}
 )
 
+// Evaluate evaluates node. An error raised while doing so is located at
+// node, unless a node inside it already claimed the error.
 func Evaluate(node ast.Node, environment *object.Environment) object.Object {
+	result := evaluate(node, environment)
+
+	if err, ok := result.(*object.Error); ok && node != nil && err.Extent == (ast.Span{}) {
+		err.Extent = node.Span()
+	}
+
+	return result
+}
+
+func evaluate(node ast.Node, environment *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evaluateProgram(node.Statements, environment)
 	case *ast.ExpressionStatement:
 		return Evaluate(node.Expression, environment)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, environment)
+	case *ast.IndexAssignStatement:
+		return evalIndexAssignStatement(node, environment)
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, environment)
+	case *ast.ForStatement:
+		return evalForStatement(node, environment)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
+	case *ast.ReturnStatement:
+		value := Evaluate(node.Value, environment)
+
+		if isError(value) || isControl(value) {
+			return value
+		}
+
+		return &object.ReturnValue{Value: unwrapPrefix(value)}
 	case *ast.NumberExpression:
 		return &object.Number{
 			Value: node.Value,
+			Big:   node.Big,
+		}
+	case *ast.FloatExpression:
+		return &object.Float{
+			Value: node.Value,
 		}
 	case *ast.StringExpression:
 		return &object.String{
 			Value: node.Value,
 		}
+	case *ast.InterpolatedStringExpression:
+		return evalInterpolatedStringExpression(node, environment)
 	case *ast.BooleanExpression:
 		return booleanObject(node.Value)
 	case *ast.PrefixExpression:
 		prefix := &object.Prefix{}
 		right := Evaluate(node.Right, environment)
 
-		if isError(right) {
+		if isError(right) || isControl(right) {
 			return right
 		}
 
-		prefix.Value = evalPrefixExpression(node.Operator, right)
+		prefix.Value = evalPrefixExpression(node.Operator, unwrapPrefix(right))
+
+		if isError(prefix.Value) {
+			return prefix.Value
+		}
+
 		return prefix
   case *ast.IdentifierExpression:
     return evalIdentifier(node, environment)
+	case *ast.ArrayExpression:
+		elements := evalExpressions(node.Elements, environment)
+
+		if len(elements) == 1 && (isError(elements[0]) || isControl(elements[0])) {
+			return elements[0]
+		}
+
+		return &object.Array{Elements: elements}
+	case *ast.HashExpression:
+		return evalHashExpression(node, environment)
+	case *ast.IndexExpression:
+		left := Evaluate(node.Left, environment)
+
+		if isError(left) || isControl(left) {
+			return left
+		}
+
+		index := Evaluate(node.Index, environment)
+
+		if isError(index) || isControl(index) {
+			return index
+		}
+
+		return evalIndexExpression(unwrapPrefix(left), unwrapPrefix(index))
+	case *ast.IfExpression:
+		return evalIfExpression(node, environment)
+	case *ast.FunctionExpression:
+		return &object.Function{
+			Parameters:  node.Parameters,
+			Body:        node.Body,
+			Environment: environment,
+		}
+	case *ast.CallExpression:
+		function := Evaluate(node.Function, environment)
+
+		if isError(function) || isControl(function) {
+			return function
+		}
+
+		arguments := evalExpressions(node.Arguments, environment)
+
+		if len(arguments) == 1 && (isError(arguments[0]) || isControl(arguments[0])) {
+			return arguments[0]
+		}
+
+		return applyFunction(function, arguments)
+	case *ast.IdentifierStatement:
+		return evalIdentifierStatement(node, environment)
+	case *ast.LogicalExpression:
+		return evalLogicalExpression(node, environment)
+	case *ast.ConditionalExpression:
+		return evalConditionalExpression(node, environment)
+	case *ast.BadExpression:
+		return newError("invalid syntax at %d:%d", node.Extent.Start.Line, node.Extent.Start.Column)
+	case *ast.BadStatement:
+		return newError("invalid syntax at %d:%d", node.Extent.Start.Line, node.Extent.Start.Column)
+	case *ast.InfixExpression:
+		left := Evaluate(node.Left, environment)
+
+		if isError(left) || isControl(left) {
+			return left
+		}
+
+		right := Evaluate(node.Right, environment)
+
+		if isError(right) || isControl(right) {
+			return right
+		}
+
+		return evalInfixExpression(node.Operator, unwrapPrefix(left), unwrapPrefix(right))
 	}
 
   return newError("TODO: object of type %+v not supported", node)
@@ -57,15 +180,45 @@ func evaluateProgram(statements []ast.Statement, environment *object.Environment
 	for _, statement := range statements {
 		result = Evaluate(statement, environment)
 
+		if isError(result) {
+			return result
+		}
+
 		switch result := result.(type) {
 		case *object.ReturnValue:
 			return result.Value
-		case *object.Error:
+		case *object.Break:
+			return newError("break outside loop")
+		case *object.Continue:
+			return newError("continue outside loop")
+		}
+	}
+
+	return result
+}
+
+// evalBlockStatement evaluates a block in its own scope. A ReturnValue is
+// passed up unchanged so that it keeps unwinding until it reaches the
+// enclosing function call or the program.
+func evalBlockStatement(block *ast.BlockStatement, environment *object.Environment) object.Object {
+	return evalStatements(block.Statements, object.NewEnclosedEnvironment(environment))
+}
+
+func evalStatements(statements []ast.Statement, environment *object.Environment) object.Object {
+	var result object.Object = NULL
+
+	for _, statement := range statements {
+		result = Evaluate(statement, environment)
+
+		if isError(result) {
 			return result
 		}
 
-		if returnValue, ok := result.(*object.ReturnValue); ok {
-			return returnValue.Value
+		if result != nil {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+				return result
+			}
 		}
 	}
 
@@ -78,6 +231,8 @@ func evalPrefixExpression(operator token.Token, right object.Object) object.Obje
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusOperatorExpression(right)
+	case "~":
+		return evalBitNotOperatorExpression(right)
 	default:
 		// TODO: return error
     return &object.Error{Message: "hehe"}
@@ -93,25 +248,851 @@ func booleanObject(input bool) *object.Boolean {
 }
 
 func evalBangOperatorExpression(right object.Object) object.Object {
-	switch right {
-	case TRUE:
-		return FALSE
-	case FALSE:
-		return TRUE
-	case NULL:
-		return TRUE
+	return booleanObject(!isTruthy(right))
+}
+
+func evalIfExpression(node *ast.IfExpression, environment *object.Environment) object.Object {
+	condition := Evaluate(node.Condition, environment)
+
+	if isError(condition) || isControl(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return evalBlockStatement(node.Consequence, environment)
+	} else if node.Alternative != nil {
+		return evalBlockStatement(node.Alternative, environment)
+	}
+
+	return NULL
+}
+
+func evalConditionalExpression(node *ast.ConditionalExpression, environment *object.Environment) object.Object {
+	condition := Evaluate(node.Condition, environment)
+
+	if isError(condition) || isControl(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return Evaluate(node.Consequence, environment)
+	}
+
+	return Evaluate(node.Alternative, environment)
+}
+
+func evalWhileStatement(node *ast.WhileStatement, environment *object.Environment) object.Object {
+	for {
+		condition := Evaluate(node.Condition, environment)
+
+		if isError(condition) || isControl(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		result := evalBlockStatement(node.Body, environment)
+
+		if stop, value := loopControl(result); stop {
+			return value
+		}
+	}
+}
+
+// evalForStatement evaluates a C-style for loop. The init statement runs in
+// a scope of its own so that loop variables do not leak after the loop.
+func evalForStatement(node *ast.ForStatement, environment *object.Environment) object.Object {
+	loopEnvironment := object.NewEnclosedEnvironment(environment)
+
+	if node.Init != nil {
+		init := Evaluate(node.Init, loopEnvironment)
+
+		if isError(init) || isControl(init) {
+			return init
+		}
+	}
+
+	for {
+		if node.Condition != nil {
+			condition := Evaluate(node.Condition, loopEnvironment)
+
+			if isError(condition) || isControl(condition) {
+				return condition
+			}
+
+			if !isTruthy(condition) {
+				return NULL
+			}
+		}
+
+		result := evalBlockStatement(node.Body, loopEnvironment)
+
+		if stop, value := loopControl(result); stop {
+			return value
+		}
+
+		if node.Post != nil {
+			post := Evaluate(node.Post, loopEnvironment)
+
+			if isError(post) || isControl(post) {
+				return post
+			}
+		}
+	}
+}
+
+// loopControl inspects the result of a loop body and reports whether the
+// loop should stop, and with which value.
+func loopControl(result object.Object) (bool, object.Object) {
+	if isError(result) {
+		return true, result
+	}
+
+	switch result.(type) {
+	case *object.Break:
+		return true, NULL
+	case *object.ReturnValue:
+		return true, result
 	default:
-		return FALSE
+		return false, nil
+	}
+}
+
+// isTruthy reports whether obj counts as true in a condition. null, false, 0,
+// the empty string, the empty array and the empty hash are falsy; every other
+// value is truthy.
+func isTruthy(obj object.Object) bool {
+	switch obj := unwrapPrefix(obj).(type) {
+	case *object.Null:
+		return false
+	case *object.Boolean:
+		return obj.Value
+	case *object.Number:
+		return obj.Sign() != 0
+	case *object.Float:
+		return obj.Value != 0
+	case *object.String:
+		return obj.Value != ""
+	case *object.Array:
+		return len(obj.Elements) > 0
+	case *object.Hash:
+		return obj.Len() > 0
+	default:
+		return true
 	}
 }
 
 func evalMinusOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.NUMBER_OBJ {
+	switch right := right.(type) {
+	case *object.Number:
+		if right.IsBig() || right.Value == math.MinInt {
+			return object.NewNumber(right.BigInt().Neg(right.BigInt()))
+		}
+		return &object.Number{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
+}
+
+// evalLogicalExpression short-circuits: the right operand is only evaluated
+// when the left one does not decide the result. The deciding operand itself
+// is returned, so `x || default` yields x whenever x is truthy. `??` only
+// falls through on null, so falsy values such as 0 or "" are kept.
+func evalLogicalExpression(node *ast.LogicalExpression, environment *object.Environment) object.Object {
+	left := Evaluate(node.Left, environment)
+
+	if isError(left) || isControl(left) {
+		return left
+	}
+
+	left = unwrapPrefix(left)
+
+	switch node.Operator.Type {
+	case token.AND:
+		if !isTruthy(left) {
+			return left
+		}
+	case token.OR:
+		if isTruthy(left) {
+			return left
+		}
+	case token.COALESCE:
+		if left.Type() != object.NULL_OBJ {
+			return left
+		}
+	default:
+		return newError("unknown operator: %s", node.Operator.Value)
+	}
+
+	right := Evaluate(node.Right, environment)
+
+	if isError(right) || isControl(right) {
+		return right
+	}
+
+	return unwrapPrefix(right)
+}
+
+func evalBitNotOperatorExpression(right object.Object) object.Object {
+	number, ok := right.(*object.Number)
+
+	if !ok {
+		return newError("bitwise operator requires NUMBER operand: ~%s", right.Type())
+	}
+
+	if number.IsBig() {
+		value := number.BigInt()
+		return object.NewNumber(value.Not(value))
+	}
+
+	return &object.Number{Value: ^number.Value}
+}
+
+func evalInfixExpression(operator token.Token, left, right object.Object) object.Object {
+	switch {
+	case isBitwiseOperator(operator):
+		if left.Type() != object.NUMBER_OBJ || right.Type() != object.NUMBER_OBJ {
+			return newError("bitwise operator requires NUMBER operands: %s %s %s", left.Type(), operator.Value, right.Type())
+		}
+		return evalBitwiseInfixExpression(operator, left.(*object.Number), right.(*object.Number))
+	case left.Type() == object.NUMBER_OBJ && right.Type() == object.NUMBER_OBJ:
+		return evalNumberInfixExpression(operator, left, right)
+	case isNumeric(left) && isNumeric(right):
+		return evalFloatInfixExpression(operator, toFloat(left), toFloat(right))
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
+		return evalBooleanInfixExpression(operator, left, right)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator.Value, right.Type())
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator.Value, right.Type())
+	}
+}
+
+// evalNumberInfixExpression evaluates integer arithmetic. Operands that fit
+// in an int take the fast path; on overflow, or when either operand is
+// already big, the operation is redone with math/big so results are exact.
+func evalNumberInfixExpression(operator token.Token, left, right object.Object) object.Object {
+	leftNumber := left.(*object.Number)
+	rightNumber := right.(*object.Number)
+
+	switch operator.Value {
+	case "/":
+		if rightNumber.Sign() == 0 {
+			return newError("division by zero: %s / %s", leftNumber.ToString(), rightNumber.ToString())
+		}
+	case "%":
+		if rightNumber.Sign() == 0 {
+			return newError("modulo by zero: %s %% %s", leftNumber.ToString(), rightNumber.ToString())
+		}
+	case "**":
+		return evalNumberPowerExpression(leftNumber, rightNumber)
+	}
+
+	if !leftNumber.IsBig() && !rightNumber.IsBig() {
+		if result, ok := evalSmallNumberInfixExpression(operator, leftNumber.Value, rightNumber.Value); ok {
+			return result
+		}
+	}
+
+	return evalBigNumberInfixExpression(operator, leftNumber.BigInt(), rightNumber.BigInt())
+}
+
+// evalNumberPowerExpression keeps integer powers exact. A negative exponent
+// has no integer result, so it is computed as a float instead.
+func evalNumberPowerExpression(left, right *object.Number) object.Object {
+	if right.Sign() < 0 {
+		if left.Sign() == 0 {
+			return newError("division by zero: %s ** %s", left.ToString(), right.ToString())
+		}
+		return &object.Float{Value: math.Pow(toFloat(left), toFloat(right))}
+	}
+
+	if right.IsBig() {
+		return newError("exponent too large: %s", right.ToString())
+	}
+
+	base := left.BigInt()
+
+	// the result has at most BitLen bits per factor; 0, 1 and -1 stay small
+	// whatever the exponent
+	if bits := base.BitLen(); bits > 1 && right.Value > maxNumberBits/bits {
+		return newError("exponent too large: %s", right.ToString())
+	}
+
+	return object.NewNumber(base.Exp(base, right.BigInt(), nil))
+}
+
+// evalSmallNumberInfixExpression reports false when the result overflows an
+// int or the operator is unknown, leaving it to the big path.
+func evalSmallNumberInfixExpression(operator token.Token, leftValue, rightValue int) (object.Object, bool) {
+	switch operator.Value {
+	case "+":
+		result := leftValue + rightValue
+		if (leftValue^result)&(rightValue^result) < 0 {
+			return nil, false
+		}
+		return &object.Number{Value: result}, true
+	case "-":
+		result := leftValue - rightValue
+		if (leftValue^rightValue)&(leftValue^result) < 0 {
+			return nil, false
+		}
+		return &object.Number{Value: result}, true
+	case "*":
+		if leftValue == 0 || rightValue == 0 {
+			return &object.Number{Value: 0}, true
+		}
+		result := leftValue * rightValue
+		if result/rightValue != leftValue || (leftValue == -1 && rightValue == math.MinInt) || (rightValue == -1 && leftValue == math.MinInt) {
+			return nil, false
+		}
+		return &object.Number{Value: result}, true
+	case "/":
+		if leftValue == math.MinInt && rightValue == -1 {
+			return nil, false
+		}
+		return &object.Number{Value: leftValue / rightValue}, true
+	case "%":
+		return &object.Number{Value: leftValue % rightValue}, true
+	case "<":
+		return booleanObject(leftValue < rightValue), true
+	case ">":
+		return booleanObject(leftValue > rightValue), true
+	case "<=":
+		return booleanObject(leftValue <= rightValue), true
+	case ">=":
+		return booleanObject(leftValue >= rightValue), true
+	case "==":
+		return booleanObject(leftValue == rightValue), true
+	case "!=":
+		return booleanObject(leftValue != rightValue), true
+	default:
+		return nil, false
+	}
+}
+
+// evalBigNumberInfixExpression truncates division towards zero, matching the
+// behaviour of the int path.
+func evalBigNumberInfixExpression(operator token.Token, leftValue, rightValue *big.Int) object.Object {
+	switch operator.Value {
+	case "+":
+		return object.NewNumber(leftValue.Add(leftValue, rightValue))
+	case "-":
+		return object.NewNumber(leftValue.Sub(leftValue, rightValue))
+	case "*":
+		return object.NewNumber(leftValue.Mul(leftValue, rightValue))
+	case "/":
+		return object.NewNumber(leftValue.Quo(leftValue, rightValue))
+	case "%":
+		return object.NewNumber(leftValue.Rem(leftValue, rightValue))
+	case "<":
+		return booleanObject(leftValue.Cmp(rightValue) < 0)
+	case ">":
+		return booleanObject(leftValue.Cmp(rightValue) > 0)
+	case "<=":
+		return booleanObject(leftValue.Cmp(rightValue) <= 0)
+	case ">=":
+		return booleanObject(leftValue.Cmp(rightValue) >= 0)
+	case "==":
+		return booleanObject(leftValue.Cmp(rightValue) == 0)
+	case "!=":
+		return booleanObject(leftValue.Cmp(rightValue) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", object.NUMBER_OBJ, operator.Value, object.NUMBER_OBJ)
+	}
+}
+
+func isBitwiseOperator(operator token.Token) bool {
+	switch operator.Type {
+	case token.BIT_AND, token.BIT_OR, token.BIT_XOR, token.SHIFT_LEFT, token.SHIFT_RIGHT:
+		return true
+	default:
+		return false
+	}
+}
+
+// evalBitwiseInfixExpression treats integers as two's complement with
+// infinite sign extension, so results agree between the int and big forms.
+func evalBitwiseInfixExpression(operator token.Token, left, right *object.Number) object.Object {
+	switch operator.Type {
+	case token.SHIFT_LEFT, token.SHIFT_RIGHT:
+		return evalShiftExpression(operator, left, right)
+	}
+
+	if !left.IsBig() && !right.IsBig() {
+		switch operator.Type {
+		case token.BIT_AND:
+			return &object.Number{Value: left.Value & right.Value}
+		case token.BIT_OR:
+			return &object.Number{Value: left.Value | right.Value}
+		case token.BIT_XOR:
+			return &object.Number{Value: left.Value ^ right.Value}
+		}
+	}
+
+	leftValue := left.BigInt()
+	rightValue := right.BigInt()
+
+	switch operator.Type {
+	case token.BIT_AND:
+		return object.NewNumber(leftValue.And(leftValue, rightValue))
+	case token.BIT_OR:
+		return object.NewNumber(leftValue.Or(leftValue, rightValue))
+	case token.BIT_XOR:
+		return object.NewNumber(leftValue.Xor(leftValue, rightValue))
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator.Value, right.Type())
+	}
+}
+
+// evalShiftExpression shifts arithmetically; left shifts that overflow an
+// int promote the result to a big integer.
+func evalShiftExpression(operator token.Token, left, right *object.Number) object.Object {
+	if right.Sign() < 0 {
+		return newError("negative shift count: %s", right.ToString())
+	}
+
+	if right.IsBig() {
+		if operator.Type == token.SHIFT_RIGHT {
+			// every bit is shifted out, leaving only the sign
+			return &object.Number{Value: min(left.Sign(), 0)}
+		}
+		return newError("shift count too large: %s", right.ToString())
+	}
+
+	count := uint(right.Value)
+
+	if operator.Type == token.SHIFT_LEFT && left.Sign() != 0 && right.Value > maxNumberBits-left.BigInt().BitLen() {
+		return newError("shift count too large: %s", right.ToString())
+	}
+
+	if operator.Type == token.SHIFT_RIGHT {
+		if left.IsBig() {
+			value := left.BigInt()
+			return object.NewNumber(value.Rsh(value, count))
+		}
+		return &object.Number{Value: left.Value >> count}
+	}
+
+	value := left.BigInt()
+
+	return object.NewNumber(value.Lsh(value, count))
+}
+
+// evalFloatInfixExpression evaluates arithmetic where at least one operand is
+// a float; integer operands are promoted to float beforehand.
+func evalFloatInfixExpression(operator token.Token, leftValue, rightValue float64) object.Object {
+	switch operator.Value {
+	case "+":
+		return &object.Float{Value: leftValue + rightValue}
+	case "-":
+		return &object.Float{Value: leftValue - rightValue}
+	case "*":
+		return &object.Float{Value: leftValue * rightValue}
+	case "/":
+		if rightValue == 0 {
+			return newError("division by zero: %s / %s", floatString(leftValue), floatString(rightValue))
+		}
+		return &object.Float{Value: leftValue / rightValue}
+	case "%":
+		if rightValue == 0 {
+			return newError("modulo by zero: %s %% %s", floatString(leftValue), floatString(rightValue))
+		}
+		return &object.Float{Value: math.Mod(leftValue, rightValue)}
+	case "**":
+		if leftValue == 0 && rightValue < 0 {
+			return newError("division by zero: %s ** %s", floatString(leftValue), floatString(rightValue))
+		}
+		return &object.Float{Value: math.Pow(leftValue, rightValue)}
+	case "<":
+		return booleanObject(leftValue < rightValue)
+	case ">":
+		return booleanObject(leftValue > rightValue)
+	case "<=":
+		return booleanObject(leftValue <= rightValue)
+	case ">=":
+		return booleanObject(leftValue >= rightValue)
+	case "==":
+		return booleanObject(leftValue == rightValue)
+	case "!=":
+		return booleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: %s %s %s", object.FLOAT_OBJ, operator.Value, object.FLOAT_OBJ)
+	}
+}
+
+func isNumeric(obj object.Object) bool {
+	return obj.Type() == object.NUMBER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Number:
+		if obj.IsBig() {
+			value, _ := new(big.Float).SetInt(obj.Big).Float64()
+			return value
+		}
+		return float64(obj.Value)
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
+func floatString(value float64) string {
+	return (&object.Float{Value: value}).ToString()
+}
+
+func evalStringInfixExpression(operator token.Token, left, right object.Object) object.Object {
+	leftValue := left.(*object.String).Value
+	rightValue := right.(*object.String).Value
+
+	switch operator.Value {
+	case "+":
+		return &object.String{Value: leftValue + rightValue}
+	case "<":
+		return booleanObject(leftValue < rightValue)
+	case ">":
+		return booleanObject(leftValue > rightValue)
+	case "<=":
+		return booleanObject(leftValue <= rightValue)
+	case ">=":
+		return booleanObject(leftValue >= rightValue)
+	case "==":
+		return booleanObject(leftValue == rightValue)
+	case "!=":
+		return booleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator.Value, right.Type())
+	}
+}
+
+func evalBooleanInfixExpression(operator token.Token, left, right object.Object) object.Object {
+	leftValue := left.(*object.Boolean).Value
+	rightValue := right.(*object.Boolean).Value
+
+	switch operator.Value {
+	case "==":
+		return booleanObject(leftValue == rightValue)
+	case "!=":
+		return booleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator.Value, right.Type())
+	}
+}
+
+// unwrapPrefix returns the value held by a Prefix object so that operators
+// can work on the underlying Number or Boolean.
+func unwrapPrefix(obj object.Object) object.Object {
+	if prefix, ok := obj.(*object.Prefix); ok {
+		return unwrapPrefix(prefix.Value)
+	}
+
+	return obj
+}
+
+func evalIdentifierStatement(node *ast.IdentifierStatement, environment *object.Environment) object.Object {
+	val := Evaluate(node.Value, environment)
+
+	if isError(val) || isControl(val) {
+		return val
+	}
+
+	val = unwrapPrefix(val)
+
+	var err *object.Error
+
+	switch node.Type.Type {
+	case token.CONST:
+		val, err = environment.Declare(node.Token.Value, val, true)
+	case token.VAR:
+		val, err = environment.Declare(node.Token.Value, val, false)
+	case token.ASSIGN:
+		val, err = environment.Assign(node.Token.Value, val)
+	default:
+		operator, ok := compoundOperator(node.Type)
+
+		if !ok {
+			return newError("unknown assignment operator: %s", node.Type.Value)
+		}
+
+		current, declared := environment.Get(node.Token.Value)
+
+		if !declared {
+			return newCodedError(object.NotDeclared, "identifier not declared: %s", node.Token.Value)
+		}
+
+		if environment.IsConstant(node.Token.Value) {
+			return newCodedError(object.ConstantAssignment, "cannot assign to constant: %s", node.Token.Value)
+		}
+
+		val = evalInfixExpression(operator, current, val)
+
+		if isError(val) {
+			return val
+		}
+
+		val, err = environment.Assign(node.Token.Value, val)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return val
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := arrayIndex(left, index)
+
+		if err != nil {
+			return err
+		}
+
+		return left.Elements[i]
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+
+		if val, ok := left.Get(key); ok {
+			return val
+		}
+
+		return NULL
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+func evalInterpolatedStringExpression(node *ast.InterpolatedStringExpression, environment *object.Environment) object.Object {
+	var out bytes.Buffer
+
+	for _, part := range node.Parts {
+		value := Evaluate(part, environment)
+
+		if isError(value) || isControl(value) {
+			return value
+		}
+
+		out.WriteString(unwrapPrefix(value).ToString())
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+func evalHashExpression(node *ast.HashExpression, environment *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for i, keyNode := range node.Keys {
+		key := Evaluate(keyNode, environment)
+
+		if isError(key) || isControl(key) {
+			return key
+		}
+
+		hashKey, ok := unwrapPrefix(key).(object.Hashable)
+
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		val := Evaluate(node.Values[i], environment)
+
+		if isError(val) || isControl(val) {
+			return val
+		}
+
+		hash.Set(hashKey, unwrapPrefix(val))
+	}
+
+	return hash
+}
+
+func evalIndexAssignStatement(node *ast.IndexAssignStatement, environment *object.Environment) object.Object {
+	left := Evaluate(node.Target.Left, environment)
+
+	if isError(left) || isControl(left) {
+		return left
+	}
+
+	index := Evaluate(node.Target.Index, environment)
+
+	if isError(index) || isControl(index) {
+		return index
+	}
+
+	val := Evaluate(node.Value, environment)
+
+	if isError(val) || isControl(val) {
+		return val
+	}
+
+	val = unwrapPrefix(val)
+	left = unwrapPrefix(left)
+	index = unwrapPrefix(index)
+
+	if node.Type.Type != token.ASSIGN {
+		operator, ok := compoundOperator(node.Type)
+
+		if !ok {
+			return newError("unknown assignment operator: %s", node.Type.Value)
+		}
+
+		current := evalIndexExpression(left, index)
+
+		if isError(current) {
+			return current
+		}
+
+		val = evalInfixExpression(operator, current, val)
+
+		if isError(val) {
+			return val
+		}
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		i, err := arrayIndex(left, index)
+
+		if err != nil {
+			return err
+		}
+
+		left.Elements[i] = val
+
+		return val
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
 
-	value := right.(*object.Number).Value
-	return &object.Number{Value: -value}
+		left.Set(key, val)
+
+		return val
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+}
+
+// arrayIndex resolves index against array, counting negative indexes from
+// the end of the array.
+func arrayIndex(array *object.Array, index object.Object) (int, *object.Error) {
+	number, ok := index.(*object.Number)
+
+	if !ok {
+		return 0, newError("array index must be NUMBER, got %s", index.Type())
+	}
+
+	if number.IsBig() {
+		return 0, newError("index out of range: %s (length %d)", number.ToString(), len(array.Elements))
+	}
+
+	i := number.Value
+	length := len(array.Elements)
+
+	if i < 0 {
+		i += length
+	}
+
+	if i < 0 || i >= length {
+		return 0, newError("index out of range: %d (length %d)", number.Value, length)
+	}
+
+	return i, nil
+}
+
+func evalExpressions(expressions []ast.Expression, environment *object.Environment) []object.Object {
+	var result []object.Object
+
+	for _, e := range expressions {
+		evaluated := Evaluate(e, environment)
+
+		if isError(evaluated) || isControl(evaluated) {
+			return []object.Object{evaluated}
+		}
+
+		result = append(result, unwrapPrefix(evaluated))
+	}
+
+	return result
+}
+
+func applyFunction(fn object.Object, arguments []object.Object) object.Object {
+	if builtin, ok := fn.(*object.Builtin); ok {
+		return builtin.Fn(arguments...)
+	}
+
+	function, ok := fn.(*object.Function)
+
+	if !ok {
+		return newError("not a function: %s", fn.Type())
+	}
+
+	if len(arguments) != len(function.Parameters) {
+		return newError("wrong number of arguments: want=%d, got=%d", len(function.Parameters), len(arguments))
+	}
+
+	environment := object.NewEnclosedEnvironment(function.Environment)
+
+	for i, parameter := range function.Parameters {
+		environment.Set(parameter.Value, arguments[i])
+	}
+
+	result := evalStatements(function.Body.Statements, environment)
+
+	switch result.(type) {
+	case *object.Break:
+		return newError("break outside loop")
+	case *object.Continue:
+		return newError("continue outside loop")
+	}
+
+	return unwrapReturnValue(result)
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+
+	return obj
+}
+
+var compoundOperators = map[token.TokenType]token.TokenType{
+	token.PLUS_ASSIGN:     token.PLUS,
+	token.MINUS_ASSIGN:    token.MINUS,
+	token.ASTERISK_ASSIGN: token.ASTERISK,
+	token.SLASH_ASSIGN:    token.SLASH,
+	token.MODULO_ASSIGN:   token.MODULO,
+}
+
+// compoundOperator returns the infix operator applied by a compound
+// assignment, e.g. + for +=.
+func compoundOperator(assignment token.Token) (token.Token, bool) {
+	operatorType, ok := compoundOperators[assignment.Type]
+
+	if !ok {
+		return token.Token{}, false
+	}
+
+	value := strings.TrimSuffix(assignment.Value, "=")
+
+	return token.New(operatorType, value, assignment.Line), true
 }
 
 func evalIdentifier(node *ast.IdentifierExpression, environment *object.Environment) object.Object {
@@ -119,9 +1100,9 @@ func evalIdentifier(node *ast.IdentifierExpression, environment *object.Environm
     return val
   }
 
-  //if builtin, ok := builtins[node.Value]; ok {
-  //  return builtin
-  //}
+  if builtin, ok := builtins[node.Value]; ok {
+    return builtin
+  }
 
   return newError("identifier not found: " + node.Value)
 }
@@ -130,6 +1111,25 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+func newCodedError(code object.ErrorCode, format string, a ...interface{}) *object.Error {
+	err := newError(format, a...)
+	err.Code = code
+
+	return err
+}
+
+// isControl reports whether obj is a return, break or continue signal. Like
+// an error, it must unwind past any expression that is being evaluated, so
+// that for example an if used as a value can still return from a function.
+func isControl(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.ReturnValue, *object.Break, *object.Continue:
+		return true
+	default:
+		return false
+	}
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -2,9 +2,11 @@ package evaluator
 
 import (
 	"testing"
+	"ziplang/ast"
 	"ziplang/lexer"
 	"ziplang/object"
 	"ziplang/parser"
+	"ziplang/token"
 )
 
 func TestEvaluatorPrefixExpression(t *testing.T) {
@@ -13,7 +15,7 @@ func TestEvaluatorPrefixExpression(t *testing.T) {
 		expectedOutput interface{}
 	}{
 		{"-1;", -1},
-		{"-true;", false},
+		{"-true;", "unknown operator: -BOOLEAN"},
 		{"!false", true},
 		{"!true", false},
 	}
@@ -27,10 +29,23 @@ func TestEvaluatorPrefixExpression(t *testing.T) {
 
 		evaluatedProgram := Evaluate(program, env)
 
+		// a failing operator returns its error unwrapped
+		if expected, ok := tc.expectedOutput.(string); ok {
+			result, ok := evaluatedProgram.(*object.Error)
+
+			if !ok {
+				t.Errorf("object.Object is not an Error. got=%T (%v)", evaluatedProgram, evaluatedProgram)
+			} else if result.Message != expected {
+				t.Errorf("wrong error message. got=%q, want=%q", result.Message, expected)
+			}
+
+			continue
+		}
+
 		wrappingResult, ok := evaluatedProgram.(*object.Prefix)
 
 		if !ok {
-			t.Errorf("object.Object is not a Prefix. got=%T (%v)", evaluatedProgram, evaluatedProgram)
+			t.Fatalf("object.Object is not a Prefix. got=%T (%v)", evaluatedProgram, evaluatedProgram)
 		}
 
 		switch wrappingResult.Value.Type() {
@@ -57,13 +72,6 @@ func TestEvaluatorPrefixExpression(t *testing.T) {
 				t.Errorf("object has wrong value. got=%+v, want=%+v", result.Value, tc.expectedOutput)
 			}
 
-		case object.ERROR_OBJ:
-			_, ok := wrappingResult.Value.(*object.Error)
-
-			if !ok {
-				t.Errorf("object.Object is not a Error. got=%T (%v)", evaluatedProgram, evaluatedProgram)
-			}
-
 		default:
 			t.Errorf("Unsupporeted value for prefixexpression. got=%+v, want=Boolean || Number", wrappingResult.Value.Type())
 		}
@@ -107,9 +115,18 @@ func TestEvalatorStringExpression(t *testing.T) {
 		input          string
 		expectedOutput interface{}
 	}{
-		{`"teststring"`, `"teststring"`},
-		{`"asd"`, `"asd"`},
-		{`"333"`, `"333"`},
+		{`"teststring"`, `teststring`},
+		{`"asd"`, `asd`},
+		{`"333"`, `333`},
+		{`""`, ``},
+		{`"a\nb"`, "a\nb"},
+		{`"tab\there"`, "tab\there"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"back\\slash"`, `back\slash`},
+		{`"\u{48}\u{e9}\u{1F600}"`, "Hé😀"},
+		{"`raw \\n ${x}`", `raw \n ${x}`},
+		{"`multi\nline`", "multi\nline"},
+		{"\"multi\nline\"", "multi\nline"},
 	}
 
 	for _, tc := range tests {
@@ -165,3 +182,1136 @@ func TestEvaluatorNumberExpression(t *testing.T) {
 		}
 	}
 }
+
+func TestEvaluatorInfixExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"1 + 2;", 3},
+		{"5 - 7;", -2},
+		{"3 * 4 + 1;", 13},
+		{"3 * (4 + 1);", 15},
+		{"7 / 2;", 3},
+		{"7 % 2;", 1},
+		{"-1 + 3;", 2},
+		{"1 < 2;", true},
+		{"1 > 2;", false},
+		{"1 == 1;", true},
+		{"1 != 1;", false},
+		{"true == true;", true},
+		{"true != false;", true},
+		{"(1 < 2) == true;", true},
+		{`"foo" + "bar";`, `foobar`},
+		{`"a" < "b";`, true},
+		{`"b" > "a";`, true},
+		{`"a" == "a";`, true},
+		{`"a" != "a";`, false},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorInfixExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"1 / 0;", "division by zero: 1 / 0"},
+		{"1 % 0;", "modulo by zero: 1 % 0"},
+		{`1 + "a";`, "type mismatch: NUMBER + STRING"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{`"a" - "b";`, "unknown operator: STRING - STRING"},
+		{"1 + true;", "type mismatch: NUMBER + BOOLEAN"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		result, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("object.Object is not an Error. got=%T (%v)", evaluated, evaluated)
+			continue
+		}
+
+		if result.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. got=%q, want=%q", result.Message, tc.expectedMessage)
+		}
+	}
+}
+
+// testObject checks an evaluation result against expected, which selects
+// the kind of object: int for a Number, float64 for a Float, bool for a
+// Boolean, nil for NULL and string for either a String or an Error message.
+func testObject(t *testing.T, input string, evaluated object.Object, expected interface{}) {
+	t.Helper()
+
+	evaluated = unwrapPrefix(evaluated)
+
+	switch expected := expected.(type) {
+	case int:
+		result, ok := evaluated.(*object.Number)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not a Number. got=%T (%v)", input, evaluated, evaluated)
+			return
+		}
+
+		if result.IsBig() || result.Value != expected {
+			t.Errorf("%s: object has wrong value. got=%s, want=%d", input, result.ToString(), expected)
+		}
+	case float64:
+		result, ok := evaluated.(*object.Float)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not a Float. got=%T (%v)", input, evaluated, evaluated)
+			return
+		}
+
+		if result.Value != expected {
+			t.Errorf("%s: object has wrong value. got=%g, want=%g", input, result.Value, expected)
+		}
+	case bool:
+		result, ok := evaluated.(*object.Boolean)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not a Boolean. got=%T (%v)", input, evaluated, evaluated)
+			return
+		}
+
+		if result.Value != expected {
+			t.Errorf("%s: object has wrong value. got=%t, want=%t", input, result.Value, expected)
+		}
+	case string:
+		switch result := evaluated.(type) {
+		case *object.String:
+			if result.Value != expected {
+				t.Errorf("%s: object has wrong value. got=%q, want=%q", input, result.Value, expected)
+			}
+		case *object.Error:
+			if result.Message != expected {
+				t.Errorf("%s: wrong error message. got=%q, want=%q", input, result.Message, expected)
+			}
+		default:
+			t.Errorf("%s: object.Object is not a String or Error. got=%T (%v)", input, evaluated, evaluated)
+		}
+	case nil:
+		if evaluated != NULL {
+			t.Errorf("%s: object is not NULL. got=%T (%v)", input, evaluated, evaluated)
+		}
+	default:
+		t.Errorf("%s: unsupported expected value %T (%v)", input, expected, expected)
+	}
+}
+
+func testEvaluate(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	program := p.Parse()
+	env := object.NewEnvironment()
+
+	return Evaluate(program, env)
+}
+
+func TestEvaluatorIdentifierStatement(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"a :: 5; a;", 5},
+		{"a := 5; a;", 5},
+		{"a := 5; a = 7; a;", 7},
+		{"a := 5; b := a + 1; b;", 6},
+		{"a := -5; a + 1;", -4},
+		{"a :: 5; a = 7;", "cannot assign to constant: a"},
+		{"a = 7;", "identifier not declared: a"},
+		{"a := 5; a := 6;", "identifier already declared: a"},
+		{"a :: 5; a :: 6;", "identifier already declared: a"},
+		{"a := b;", "identifier not found: b"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorIdentifierStatementScopes(t *testing.T) {
+	outer := object.NewEnvironment()
+	Evaluate(parser.New(lexer.New("a := 1; c :: 3;")).Parse(), outer)
+
+	inner := object.NewEnclosedEnvironment(outer)
+
+	evaluated := Evaluate(parser.New(lexer.New("a = 2; b := 5; c := 4;")).Parse(), inner)
+	if isError(evaluated) {
+		t.Fatalf("unexpected error: %s", evaluated.ToString())
+	}
+
+	if a, _ := outer.Get("a"); a.(*object.Number).Value != 2 {
+		t.Errorf("assignment did not update enclosing scope. got=%s, want=2", a.ToString())
+	}
+
+	if _, ok := outer.Get("b"); ok {
+		t.Errorf("declaration in inner scope leaked into enclosing scope")
+	}
+
+	if c, _ := inner.Get("c"); c.(*object.Number).Value != 4 {
+		t.Errorf("inner declaration did not shadow outer constant. got=%s, want=4", c.ToString())
+	}
+
+	if c, _ := outer.Get("c"); c.(*object.Number).Value != 3 {
+		t.Errorf("outer constant was modified. got=%s, want=3", c.ToString())
+	}
+}
+
+func TestEvaluatorFunctionExpression(t *testing.T) {
+	evaluated := testEvaluate("fn(a, b) { a + b };")
+
+	function, ok := evaluated.(*object.Function)
+
+	if !ok {
+		t.Fatalf("object.Object is not a Function. got=%T (%v)", evaluated, evaluated)
+	}
+
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function has wrong number of parameters. got=%d, want=2", len(function.Parameters))
+	}
+
+	if function.ToString() != "fn(a, b)" {
+		t.Errorf("wrong ToString(). got=%q, want=%q", function.ToString(), "fn(a, b)")
+	}
+}
+
+func TestEvaluatorCallExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"identity :: fn(x) { x }; identity(5);", 5},
+		{"add :: fn(a, b) { a + b }; add(2, 3);", 5},
+		{"add :: fn(a, b) { a + b }; add(add(1, 2), add(3, 4));", 10},
+		{"negate :: fn(x) { -x }; negate(3) + 1;", -2},
+		{"noop :: fn() { }; noop();", nil},
+		{"adder :: fn(x) { fn(y) { x + y } }; addTwo :: adder(2); addTwo(3);", 5},
+		{"counter := 0; inc :: fn() { counter = counter + 1 }; inc(); inc(); counter;", 2},
+		{"x := 1; shadow :: fn(x) { x := 10; x }; shadow(5);", "identifier already declared: x"},
+		{"x := 1; shadow :: fn() { x := 10; x }; shadow() + x;", 11},
+		{"later :: fn() { value }; value :: 7; later();", 7},
+		{"self :: fn() { self }; self()()() == self;", "unknown operator: FUNCTION == FUNCTION"},
+		{"add :: fn(a, b) { a + b }; add(1);", "wrong number of arguments: want=2, got=1"},
+		{"add :: fn(a, b) { a + b }; add(1, 2, 3);", "wrong number of arguments: want=2, got=3"},
+		{"x :: 5; x();", "not a function: NUMBER"},
+		{"f :: fn(x) { x }; f(y);", "identifier not found: y"},
+		{"f :: fn() { 1 / 0 }; f() + 1;", "division by zero: 1 / 0"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorReturnStatement(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"return -3;", -3},
+		{"{ return 1; 2; } 3;", 1},
+		{"{ { return 1; } 2; } 3;", 1},
+		{"f :: fn() { return 1; 2; }; f() + 10;", 11},
+		{"f :: fn() { { { return 1; } 2; } 3; }; f() + 10;", 11},
+		{"f :: fn() { g :: fn() { return 1; }; g(); 2; }; f();", 2},
+		{"f :: fn() { return fn() { return 5; }; }; f()();", 5},
+		{"f :: fn() { return 1 / 0; 2; }; f();", "division by zero: 1 / 0"},
+		{"{ 1 / 0; 2; } 3;", "division by zero: 1 / 0"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorBlockStatementScope(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"x := 1; { x := 2; } x;", 1},
+		{"x := 1; { x = 2; } x;", 2},
+		{"x := 1; { x = 5 }; x", 5},
+		{"x := 1; { { x = 6 }; }; x", 6},
+		{"x :: 1; { x :: 2; x; }", 2},
+		{"{ y := 1; } y;", "identifier not found: y"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorIfExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"if true { 10 }", 10},
+		{"if false { 10 }", nil},
+		{"if 1 { 10 }", 10},
+		{"if 0 { 10 }", nil},
+		{"if -1 { 10 }", 10},
+		{"if 1 < 2 { 10 } else { 20 }", 10},
+		{"if 1 > 2 { 10 } else { 20 }", 20},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if str(1) { 10 }", 10},
+		{"if fn() {} { 10 }", 10},
+		{"if len { 10 }", 10},
+		{"if if false { 1 } { 10 } else { 20 }", 20},
+		{"x := 3; if x == 1 { 10 } else if x == 2 { 20 } else if x == 3 { 30 } else { 40 }", 30},
+		{"x := 5; if x == 1 { 10 } else if x == 2 { 20 } else { 40 }", 40},
+		{"x := 5; if x == 1 { 10 } else if x == 2 { 20 }", nil},
+		{"x := if true { 1 } else { 2 }; x + 1;", 2},
+		{"x := 1; if true { x := 2; } x;", 1},
+		{"x := 1; if true { x = 2; } x;", 2},
+		{"f :: fn(n) { if n < 0 { return -1; } n }; f(-5);", -1},
+		{"f :: fn(n) { if n < 0 { return -1; } n }; f(5);", 5},
+		{"fact :: fn(n) { if n < 2 { return 1; } n * fact(n - 1) }; fact(5);", 120},
+		{"fib :: fn(n) { if n < 2 { n } else { fib(n - 1) + fib(n - 2) } }; fib(10);", 55},
+		{"if 1 / 0 { 10 }", "division by zero: 1 / 0"},
+		{"if true { 1 / 0 } else { 10 }", "division by zero: 1 / 0"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorBangTruthiness(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput bool
+	}{
+		{"!0", true},
+		{"!1", false},
+		{"!str(1)", false},
+		{"!!true", true},
+		{`!""`, true},
+		{`!"a"`, false},
+		{"!fn() {}", false},
+		{"!if false { 1 }", true},
+	}
+
+	for _, tc := range tests {
+		evaluated := unwrapPrefix(testEvaluate(tc.input))
+
+		result, ok := evaluated.(*object.Boolean)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not a Boolean. got=%T (%v)", tc.input, evaluated, evaluated)
+			continue
+		}
+
+		if result.Value != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%t, want=%t", tc.input, result.Value, tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorLoops(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"i := 0; while i < 5 { i = i + 1; } i;", 5},
+		{"i := 0; while false { i = i + 1; } i;", 0},
+		{"sum := 0; for i := 0; i < 5; i = i + 1 { sum = sum + i; } sum;", 10},
+		{"sum := 0; for i := 0; i < 5; i = i + 1 { if i == 3 { break; } sum = sum + i; } sum;", 3},
+		{"sum := 0; for i := 0; i < 5; i = i + 1 { if i % 2 == 0 { continue; } sum = sum + i; } sum;", 4},
+		{"i := 0; while true { i = i + 1; if i == 10 { break } } i;", 10},
+		{"i := 0; n := 0; while i < 5 { i = i + 1; if i == 2 { continue } n = n + 1; } n;", 4},
+		{"i := 0; for ; i < 3; { i = i + 1; } i;", 3},
+		{"i := 0; for ;; { i = i + 1; if i > 7 { break; } } i;", 8},
+		{"n := 0; for i := 0; i < 3; i = i + 1 { for j := 0; j < 3; j = j + 1 { if j == 1 { break; } n = n + 1; } } n;", 3},
+		{"f :: fn() { for i := 0; i < 10; i = i + 1 { if i == 4 { return i; } } -1 }; f();", 4},
+		{"f :: fn() { i := 0; while true { { if i == 6 { return i; } } i = i + 1; } }; f();", 6},
+		{"for i := 0; i < 3; i = i + 1 { } i;", "identifier not found: i"},
+		{"for i := 0; i < 3; i = i + 1 { x := i; } 1;", 1},
+		{"i := 0; while i < 5 { i += 1 }; i", 5},
+		{"for i := 0; i < 3; i += 1 { }; 1", 1},
+		{"n := 0; for ;; { n += 1; if n == 2 { break }; }; n", 2},
+		{"while 1 / 0 { }", "division by zero: 1 / 0"},
+		{"for i := 0; i < 3; i = i / 0 { }", "division by zero: 0 / 0"},
+		{"break;", "break outside loop"},
+		{"continue;", "continue outside loop"},
+		{"f :: fn() { break; }; for ;; { f(); }", "break outside loop"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorArrayExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"[]", "[]"},
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{"[-1, true, fn(x) { x }, [1]]", "[-1, true, fn(x), [1]]"},
+		{"a := [1, 2, 3]; a[0] = 10; a;", "[10, 2, 3]"},
+		{"a := [1, 2, 3]; a[-1] = 10; a;", "[1, 2, 10]"},
+		{"a := [[1], [2]]; a[1][0] = 5; a;", "[[1], [5]]"},
+		{"a := [1]; b := a; b[0] = 2; a;", "[2]"},
+		{"a :: [1]; a[0] = 2; a;", "[2]"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		result, ok := evaluated.(*object.Array)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not an Array. got=%T (%v)", tc.input, evaluated, evaluated)
+			continue
+		}
+
+		if result.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, result.ToString(), tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorIndexExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][2]", 3},
+		{"[1, 2, 3][1 + 1]", 3},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"a := [1, 2, 3]; i := 1; a[i] + a[i + 1];", 5},
+		{"a := [[1, 2], [3, 4]]; a[1][0];", 3},
+		{"f :: fn() { [4, 5] }; f()[1];", 5},
+		{"a := [1, 2]; a[1] = a[0] + 10;", 11},
+		{"[1, 2, 3][3]", "index out of range: 3 (length 3)"},
+		{"[1, 2, 3][-4]", "index out of range: -4 (length 3)"},
+		{"[][0]", "index out of range: 0 (length 0)"},
+		{"[1][true]", "array index must be NUMBER, got BOOLEAN"},
+		{"1[0]", "index operator not supported: NUMBER"},
+		{"a := 1; a[0] = 2;", "index assignment not supported: NUMBER"},
+		{"a := [1]; a[1] = 2;", "index out of range: 1 (length 1)"},
+		{"[1, 1 / 0]", "division by zero: 1 / 0"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorHashExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"h := {}; h;", "{}"},
+		{"{1: 2, true: 3, 1 + 1: 4}", "{1: 2, true: 3, 2: 4}"},
+		{"{3: 1, 1: 2, 2: 3}", "{3: 1, 1: 2, 2: 3}"},
+		{"{1: 1, 2: 2, 1: 3}", "{1: 3, 2: 2}"},
+		{"{1: -1, 2: [1], 3: {4: 5}}", "{1: -1, 2: [1], 3: {4: 5}}"},
+		{"x := 1; {x: fn(a) { a }}", "{1: fn(a)}"},
+		{`x := {"a": "b", 1: ["c", 2]}; x;`, `{"a": "b", 1: ["c", 2]}`},
+		{"h := {1: 1}; h[2] = 2; h[1] = 3; h;", "{1: 3, 2: 2}"},
+		{"h := {1: 1, 2: 2, 3: 3}; delete(h, 2); h[2] = 4; h;", "{1: 1, 3: 3, 2: 4}"},
+		{"f :: fn() { {1: 2} }; f();", "{1: 2}"},
+		{"{ {1: 2} }", "{1: 2}"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		result, ok := evaluated.(*object.Hash)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not a Hash. got=%T (%v)", tc.input, evaluated, evaluated)
+			continue
+		}
+
+		if result.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, result.ToString(), tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorHashIndexExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"{1: 10, true: 20}[1]", 10},
+		{"{1: 10, true: 20}[true]", 20},
+		{"{1: 10, true: 20}[1 == 1]", 20},
+		{`{"a": 1, "b": 2}["b"]`, 2},
+		{`k := "a"; {"a": 1}[k]`, 1},
+		{"h := {-1: 5}; h[-1];", 5},
+		{"{1: 10}[2]", nil},
+		{"h := {}; h[1];", nil},
+		{"h := {}; h[1] = 5; h[1];", 5},
+		{"h := {2 ** 64: 1, 2 ** 65: 2}; h[2 ** 65]", 2},
+		{"h := {1 << 64: 1}; h[(1 << 64) + 1 - 1]", 1},
+		{"h := {1 << 64: 1}; h[(1 << 64) - 1]", nil},
+		{`h := {"1": 1, 1: 2}; h["1"]`, 1},
+		{"h := {1: {2: 3}}; h[1][2] = 4; h[1][2];", 4},
+		{"{1: 10}[[1]]", "unusable as hash key: ARRAY"},
+		{"h := {[1]: 10};", "unusable as hash key: ARRAY"},
+		{"h := {}; h[fn() {}] = 1;", "unusable as hash key: FUNCTION"},
+		{"{1: 1 / 0}", "division by zero: 1 / 0"},
+		{"if {} { 1 } else { 2 }", 2},
+		{"if {1: 1} { 1 } else { 2 }", 1},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorFloatExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"3.14", 3.14},
+		{"1e3", 1000.0},
+		{"2.5e-1", 0.25},
+		{"1E+2", 100.0},
+		{"-1.5", -1.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 + 1", 1.5},
+		{"3 * 0.5", 1.5},
+		{"1 / 2.0", 0.5},
+		{"7.5 % 2", 1.5},
+		{"2.0 - 3", -1.0},
+		{"1 / 2", 0},
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1 == 1.0", true},
+		{"1 != 1.0", false},
+		{"0.1 + 0.2 == 0.3", false},
+		{"if 0.0 { 1 } else { 2 }", 2},
+		{"1.0 / 0", "division by zero: 1.0 / 0.0"},
+		{"1 % 0.0", "modulo by zero: 1.0 % 0.0"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorBigNumbers(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"-9223372036854775807 - 1", "-9223372036854775808"},
+		{"4611686018427387904 * 2", "9223372036854775808"},
+		{"4611686018427387904 * -2", "-9223372036854775808"},
+		{"x := -9223372036854775807 - 1; x / -1", "9223372036854775808"},
+		{"x := -9223372036854775807 - 1; -x", "9223372036854775808"},
+		{"x := -9223372036854775807 - 1; x % -1", "0"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"123456789012345678901234567890 - 123456789012345678901234567889", "1"},
+		{"99999999999999999999 / 3", "33333333333333333333"},
+		{"-99999999999999999999 / 7", "-14285714285714285714"},
+		{"-99999999999999999999 % 7", "-1"},
+		{"(9223372036854775807 + 1) - 1", "9223372036854775807"},
+		{"f := 1; for i := 1; i < 26; i = i + 1 { f = f * i; } f;", "15511210043330985984000000"},
+		{"--9223372036854775808", "9223372036854775808"},
+	}
+
+	for _, tc := range tests {
+		evaluated := unwrapPrefix(testEvaluate(tc.input))
+
+		result, ok := evaluated.(*object.Number)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not a Number. got=%T (%v)", tc.input, evaluated, evaluated)
+			continue
+		}
+
+		if result.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, result.ToString(), tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorBigNumberComparison(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"99999999999999999999 > 1", true},
+		{"-99999999999999999999 < 1", true},
+		{"99999999999999999999 == 99999999999999999999", true},
+		{"99999999999999999999 != 99999999999999999998", true},
+		{"(9223372036854775807 + 1) - 1 == 9223372036854775807", true},
+		{"99999999999999999999 > 1.5", true},
+		{"99999999999999999999 * 1.0 == 1e20", true},
+		{"{99999999999999999999: 1}[99999999999999999998 + 1]", 1},
+		{"{9223372036854775807: 2}[(9223372036854775807 + 1) - 1]", 2},
+		{"if 99999999999999999999 - 99999999999999999999 { 1 } else { 2 }", 2},
+		{"[1][99999999999999999999]", "index out of range: 99999999999999999999 (length 1)"},
+		{"99999999999999999999 / 0", "division by zero: 99999999999999999999 / 0"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorInterpolatedStringExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{`name := "ziplang"; count := 2; "hello ${name}, you have ${count + 1} items"`, "hello ziplang, you have 3 items"},
+		{`"${1}"`, "1"},
+		{`"${1}${2}"`, "12"},
+		{`"a${-1}b"`, "a-1b"},
+		{`"${true} ${1.5} ${[1, "x"]} ${{1: 2}}"`, `true 1.5 [1, "x"] {1: 2}`},
+		{`"${"nested ${"deep"}"}"`, "nested deep"},
+		{`h := {1: "one"}; "${h[1]}!"`, "one!"},
+		{`f :: fn(x) { "<${x}>" }; "${f(1)}${f(2)}"`, "<1><2>"},
+		{`"price: \${x} and $5"`, "price: ${x} and $5"},
+		{"`raw ${x}`", "raw ${x}"},
+		{`"a ${ fn() { { 1 } }() } b"`, "a 1 b"},
+		{`"${ fn() { h := {1: 2}; h[1] }() }"`, "2"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		result, ok := evaluated.(*object.String)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not a String. got=%T (%v)", tc.input, evaluated, evaluated)
+			continue
+		}
+
+		if result.Value != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%q, want=%q", tc.input, result.Value, tc.expectedOutput)
+		}
+	}
+
+	evaluated := testEvaluate(`"${1 / 0}"`)
+
+	if result, ok := evaluated.(*object.Error); !ok || result.Message != "division by zero: 1 / 0" {
+		t.Errorf("expected division by zero error. got=%T (%v)", evaluated, evaluated)
+	}
+}
+
+func TestEvaluatorLogicalExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false && true", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"true || false", true},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 < 3", true},
+		{"x := 0; x != 0 && 10 / x > 1", false},
+		{"x := 0; x == 0 || 10 / x > 1", true},
+		{"x := 5; x != 0 && 10 / x > 1", true},
+		{"0 || 7", 7},
+		{"3 || 7", 3},
+		{"0 && 7", 0},
+		{"3 && 7", 7},
+		{`"" || "fallback"`, "fallback"},
+		{"false || false || 9", 9},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"calls := 0; f :: fn() { calls = calls + 1; true }; false && f(); true || f(); calls;", 0},
+		{"true && 1 / 0", "division by zero: 1 / 0"},
+		{"1 / 0 || true", "division by zero: 1 / 0"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorComparisonOperators(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput bool
+	}{
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"1.5 <= 1.5", true},
+		{"2 >= 1.5", true},
+		{"99999999999999999999 >= 99999999999999999999", true},
+		{"99999999999999999999 <= 1", false},
+		{`"a" <= "a"`, true},
+		{`"b" >= "c"`, false},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		result, ok := evaluated.(*object.Boolean)
+
+		if !ok {
+			t.Errorf("%s: object.Object is not a Boolean. got=%T (%v)", tc.input, evaluated, evaluated)
+			continue
+		}
+
+		if result.Value != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%t, want=%t", tc.input, result.Value, tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorCompoundAssignment(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput interface{}
+	}{
+		{"x := 1; x += 2; x;", 3},
+		{"x := 1; x -= 2; x;", -1},
+		{"x := 3; x *= 4; x;", 12},
+		{"x := 7; x /= 2; x;", 3},
+		{"x := 7; x %= 4; x;", 3},
+		{"x := 1; x += 2;", 3},
+		{`s := "a"; s += "b"; s;`, "ab"},
+		{"x := 1.5; x *= 2; x == 3;", true},
+		{"x := 9223372036854775807; x += 1; x > 9223372036854775807;", true},
+		{"x := 0; { x += 5; } x;", 5},
+		{"x := 0; f :: fn() { x += 1; }; f(); f(); x;", 2},
+		{"sum := 0; for i := 1; i <= 4; i += 1 { sum += i; } sum;", 10},
+		{"a := [1, 2]; a[0] += 10; a[-1] *= 3; a[0] + a[1];", 17},
+		{"h := {1: 1}; h[1] += 1; h[1];", 2},
+		{"x :: 1; x += 1;", "cannot assign to constant: x"},
+		{"y += 1;", "identifier not declared: y"},
+		{"x := 1; x /= 0;", "division by zero: 1 / 0"},
+		{`x := 1; x += "a";`, "type mismatch: NUMBER + STRING"},
+		{"h := {}; h[1] += 1;", "type mismatch: NULL + NUMBER"},
+		{"a := [1]; a[3] += 1;", "index out of range: 3 (length 1)"},
+	}
+
+	for _, tc := range tests {
+		evaluated := testEvaluate(tc.input)
+
+		testObject(t, tc.input, evaluated, tc.expectedOutput)
+	}
+}
+
+func TestEvaluatorBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"12 & 10", "8"},
+		{"12 | 10", "14"},
+		{"12 ^ 10", "6"},
+		{"~5", "-6"},
+		{"~-1", "0"},
+		{"-8 & 7", "0"},
+		{"1 << 4", "16"},
+		{"-16 >> 2", "-4"},
+		{"1 | 2 ^ 3 & 4 << 1", "3"},
+		{"(1 << 2) + 1", "5"},
+		{"1 << 2 + 1", "8"},
+		{"1 << 64", "18446744073709551616"},
+		{"(1 << 64) >> 63", "2"},
+		{"(1 << 64) | 1", "18446744073709551617"},
+		{"~(1 << 64)", "-18446744073709551617"},
+		{"(1 << 64) & ((1 << 64) - 1)", "0"},
+		{"(1 << 64) ^ (1 << 64)", "0"},
+		{"flags := 0; flags = flags | 4; flags & 4 == 4;", "true"},
+		{"1 << -1", "negative shift count: -1"},
+		{"1 >> -2", "negative shift count: -2"},
+		{"1 << 99999999999999999999", "shift count too large: 99999999999999999999"},
+		{"1 << 99999999999999", "shift count too large: 99999999999999"},
+		{"(1 << 4194300) << 10", "shift count too large: 10"},
+		{"0 << 99999999999999", "0"},
+		{"1 >> 99999999999999", "0"},
+		{"1 >> 99999999999999999999999", "0"},
+		{"-5 >> 99999999999999999999999", "-1"},
+		{"(1 << 70) >> 99999999999999999999999", "0"},
+		{"-(1 << 70) >> 99999999999999999999999", "-1"},
+		{"0 >> 99999999999999999999999", "0"},
+		{"(1 << 4194303) > 0", "true"},
+		{"1.5 & 1", "bitwise operator requires NUMBER operands: FLOAT & NUMBER"},
+		{`"a" << 1`, "bitwise operator requires NUMBER operands: STRING << NUMBER"},
+		{"true | false", "bitwise operator requires NUMBER operands: BOOLEAN | BOOLEAN"},
+		{"~1.5", "bitwise operator requires NUMBER operand: ~FLOAT"},
+	}
+
+	for _, tc := range tests {
+		evaluated := unwrapPrefix(testEvaluate(tc.input))
+
+		if result, ok := evaluated.(*object.Error); ok {
+			if result.Message != tc.expectedOutput {
+				t.Errorf("%s: wrong error message. got=%q, want=%q", tc.input, result.Message, tc.expectedOutput)
+			}
+			continue
+		}
+
+		if evaluated.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, evaluated.ToString(), tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorPowerOperator(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"2 ** 10", "1024"},
+		{"2 ** 3 ** 2", "512"},
+		{"(2 ** 3) ** 2", "64"},
+		{"2 ** 3 ** 2 == 512", "true"},
+		{"2 * 3 ** 2", "18"},
+		{"-2 ** 2", "-4"},
+		{"(-2) ** 2", "4"},
+		{"-2 ** 2 == -4", "true"},
+		{"!2 ** 0", "false"},
+		{"-2 ** 2 * 3", "-12"},
+		{"2 ** 99999999999999", "exponent too large: 99999999999999"},
+		{"(2 ** 64) ** 99999", "exponent too large: 99999"},
+		{"3 ** 4194304", "exponent too large: 4194304"},
+		{"3 ** 2097153", "exponent too large: 2097153"},
+		{"(-3) ** 2097153", "exponent too large: 2097153"},
+		{"3 ** 40 == 12157665459056928801", "true"},
+		{"1 ** 99999999999999", "1"},
+		{"(-1) ** 99999999999999", "-1"},
+		{"0 ** 99999999999999", "0"},
+		{"2 ** -1", "0.5"},
+		{"0 ** 0", "1"},
+		{"(-3) ** 3", "-27"},
+		{"2 ** 64", "18446744073709551616"},
+		{"10 ** 20 / 10 ** 19", "10"},
+		{"(2 ** 64) ** 2", "340282366920938463463374607431768211456"},
+		{"2.0 ** 3", "8.0"},
+		{"4 ** 0.5", "2.0"},
+		{"1.5 ** 2", "2.25"},
+		{"0 ** -1", "division by zero: 0 ** -1"},
+		{"0.0 ** -1", "division by zero: 0.0 ** -1.0"},
+		{"2 ** 99999999999999999999", "exponent too large: 99999999999999999999"},
+		{`"a" ** 2`, "type mismatch: STRING ** NUMBER"},
+	}
+
+	for _, tc := range tests {
+		evaluated := unwrapPrefix(testEvaluate(tc.input))
+
+		if result, ok := evaluated.(*object.Error); ok {
+			if result.Message != tc.expectedOutput {
+				t.Errorf("%s: wrong error message. got=%q, want=%q", tc.input, result.Message, tc.expectedOutput)
+			}
+			continue
+		}
+
+		if evaluated.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, evaluated.ToString(), tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorConditionalExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"true ? 1 : 2", "1"},
+		{"false ? 1 : 2", "2"},
+		{"0 ? 1 : 2", "2"},
+		{`"" ? "yes" : "no"`, "no"},
+		{"1 < 2 ? 3 + 4 : 5", "7"},
+		{"x := 5; x > 3 ? x > 4 ? 2 : 1 : 0", "2"},
+		{"false ? 1 : false ? 2 : 3", "3"},
+		{"x := true ? [1, 2] : {}; len(x)", "2"},
+		{"h := {1: true ? 1 : 0}; h[1]", "1"},
+		{"true ? 1 : undefined", "1"},
+		{"false ? undefined : 2", "2"},
+		{"true ? undefined : 2", "identifier not found: undefined"},
+	}
+
+	for _, tc := range tests {
+		evaluated := unwrapPrefix(testEvaluate(tc.input))
+
+		if result, ok := evaluated.(*object.Error); ok {
+			if result.Message != tc.expectedOutput {
+				t.Errorf("%s: wrong error message. got=%q, want=%q", tc.input, result.Message, tc.expectedOutput)
+			}
+			continue
+		}
+
+		if evaluated.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, evaluated.ToString(), tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorCoalesceExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"h := {}; h[1] ?? 2", "2"},
+		{"h := {1: 0}; h[1] ?? 2", "0"},
+		{`"" ?? "default"`, ""},
+		{"false ?? true", "false"},
+		{"[][0] ?? 1", "index out of range: 0 (length 0)"},
+		{"h := {}; h[1] ?? h[2] ?? 3", "3"},
+		{"h := {}; h[1] ?? 1 + 2", "3"},
+		{"h := {}; h[1] ?? false || true", "true"},
+		{"h := {}; h[1] ?? true ? 1 : 2", "1"},
+		{"1 ?? undefined", "1"},
+		{"h := {}; h[1] ?? undefined", "identifier not found: undefined"},
+	}
+
+	for _, tc := range tests {
+		evaluated := unwrapPrefix(testEvaluate(tc.input))
+
+		if result, ok := evaluated.(*object.Error); ok {
+			if result.Message != tc.expectedOutput {
+				t.Errorf("%s: wrong error message. got=%q, want=%q", tc.input, result.Message, tc.expectedOutput)
+			}
+			continue
+		}
+
+		if evaluated.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, evaluated.ToString(), tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"0x1F", "31"},
+		{"0XFF", "255"},
+		{"0o17", "15"},
+		{"0b1010", "10"},
+		{"1_000_000", "1000000"},
+		{"0xdead_beef", "3735928559"},
+		{"0b1111_0000 | 0b0000_1111", "255"},
+		{"010", "10"},
+		{"0x1_0000_0000_0000_0000", "18446744073709551616"},
+		{"99_999_999_999_999_999_999", "99999999999999999999"},
+		{"1_000.5", "1000.5"},
+		{"-0x10", "-16"},
+		{"user_id := 7; user_id", "7"},
+		{"x2 := 3; _y := 4; x2 * _y", "12"},
+	}
+
+	for _, tc := range tests {
+		evaluated := unwrapPrefix(testEvaluate(tc.input))
+
+		if result, ok := evaluated.(*object.Error); ok {
+			t.Errorf("%s: unexpected error: %s", tc.input, result.Message)
+			continue
+		}
+
+		if evaluated.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, evaluated.ToString(), tc.expectedOutput)
+		}
+	}
+}
+
+func TestEvaluatorComments(t *testing.T) {
+	input := `
+/// Doubles x.
+double :: fn(x) { x * 2 }; // trailing
+/* block /* nested */ */
+result := double(/* inline */ 21);
+result
+`
+
+	evaluated := testEvaluate(input)
+
+	result, ok := evaluated.(*object.Number)
+
+	if !ok {
+		t.Fatalf("object.Object is not a Number. got=%T (%v)", evaluated, evaluated)
+	}
+
+	if result.Value != 42 {
+		t.Errorf("object has wrong value. got=%d, want=42", result.Value)
+	}
+}
+
+func TestEvaluatorBadNodes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x := 1;\ny := ;\nx", "invalid syntax at 2:6"},
+		{"while x 1;", "invalid syntax at 1:1"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEvaluate(test.input)
+
+		err, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("%q: object.Object is not an Error. got=%T (%v)", test.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Message != test.expected {
+			t.Errorf("%q: wrong error message. got=%q, want=%q", test.input, err.Message, test.expected)
+		}
+	}
+}
+
+func TestEvaluatorErrorLocation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ast.Span
+	}{
+		{"x := 1;\ny := x / 0;", ast.Span{
+			Start: token.Position{Line: 2, Column: 6, Offset: 13},
+			End:   token.Position{Line: 2, Column: 11, Offset: 18},
+		}},
+		{"f :: fn() { -true };\nf()", ast.Span{
+			Start: token.Position{Line: 1, Column: 13, Offset: 12},
+			End:   token.Position{Line: 1, Column: 18, Offset: 17},
+		}},
+		{"z = 1;", ast.Span{
+			Start: token.Position{Line: 1, Column: 1, Offset: 0},
+			End:   token.Position{Line: 1, Column: 7, Offset: 6},
+		}},
+	}
+
+	for _, test := range tests {
+		evaluated := testEvaluate(test.input)
+
+		err, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("%q: object.Object is not an Error. got=%T (%v)", test.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Extent != test.expected {
+			t.Errorf("%q: wrong error location. got=%+v, want=%+v", test.input, err.Extent, test.expected)
+		}
+	}
+}
+
+func TestEvaluatorPrefixErrors(t *testing.T) {
+	tests := []string{
+		"-true + 1",
+		"1 + -true",
+		"[1, -true]",
+		"{1: -true}",
+		"if -true { 1 } else { 2 }",
+		"-true; 5",
+		"f :: fn() { -true; 1 }; f()",
+		"while -true { 1 }",
+		"for i := 0; i < 3; i += 1 { -true }",
+		"x := -true; x",
+		"-true",
+		"!-true",
+		"--true",
+	}
+
+	for _, input := range tests {
+		evaluated := testEvaluate(input)
+
+		err, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("%q: object.Object is not an Error. got=%T (%v)", input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Message != "unknown operator: -BOOLEAN" {
+			t.Errorf("%q: wrong error message. got=%q", input, err.Message)
+		}
+	}
+}
+
+func TestEvaluatorControlInExpressions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"f :: fn(x) { y := if x { return 7 }; 0 }; f(true)", "7"},
+		{"f :: fn(x) { x + if x > 0 { return 100 } else { 0 } }; f(1)", "100"},
+		{"f :: fn(x) { x + if x > 0 { return 100 } else { 0 } }; f(-1)", "-1"},
+		{"f :: fn() { [1, if true { return 2 }] }; f()", "2"},
+		{"f :: fn() { {1: if true { return 3 }} }; f()", "3"},
+		{`f :: fn() { "a${if true { return 4 }}" }; f()`, "4"},
+		{"f :: fn() { h := {}; h[if true { return 5 }] = 1; 0 }; f()", "5"},
+		{"f :: fn() { g :: fn(a) { a }; g(if true { return 6 }); 0 }; f()", "6"},
+		{"f :: fn() { -if true { return 8 } }; f()", "8"},
+		{"f :: fn() { true && if true { return 9 } }; f()", "9"},
+		{"h := {}; n := 0; while true { n += 1; h[1] = if true { break } }; len(h)", "0"},
+		{"h := {}; i := 0; while i < 3 { i += 1; h[i] = if i == 2 { continue } else { i } }; h", "{1: 1, 3: 3}"},
+		{"n := 0; for i := 0; i < 3; i += 1 { a := [if true { continue }]; n += 1 }; n", "0"},
+		{"a := [if true { continue }]", "continue outside loop"},
+		{"x := if true { break }", "break outside loop"},
+	}
+
+	for _, tc := range tests {
+		evaluated := unwrapPrefix(testEvaluate(tc.input))
+
+		if result, ok := evaluated.(*object.Error); ok {
+			if result.Message != tc.expectedOutput {
+				t.Errorf("%s: wrong error message. got=%q, want=%q", tc.input, result.Message, tc.expectedOutput)
+			}
+			continue
+		}
+
+		if evaluated.ToString() != tc.expectedOutput {
+			t.Errorf("%s: object has wrong value. got=%s, want=%s", tc.input, evaluated.ToString(), tc.expectedOutput)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"fmt"
+	"strconv"
 	"unicode"
 	"unicode/utf8"
 	"ziplang/token"
@@ -8,46 +10,124 @@ import (
 
 type Lexer struct {
 	source   string
+	file     string
 	char     rune
-	position int
+	offset   int // byte offset of char
+	position int // byte offset of the rune after char
 	line     int
+	column   int // 1-based rune column of char
+	next     int // 1-based rune column of the rune after char
+
+	// open brace count for each ${ we are inside of, innermost last
+	interpolations []int
 }
 
 func New(source string) *Lexer {
+	return NewFile("", source)
+}
+
+// NewFile creates a lexer whose tokens record file as their origin.
+func NewFile(file string, source string) *Lexer {
 	l := &Lexer{
 		source:   source,
+		file:     file,
 		char:     0,
 		position: 0,
 		line:     1,
+		next:     1,
 	}
 
 	return l
 }
 
+// State is a saved lexer position, see Save and Restore.
+type State struct {
+	lexer Lexer
+}
+
+// Save returns the current position so that tokens can be read ahead and
+// the lexer rewound with Restore.
+func (lexer *Lexer) Save() State {
+	state := State{lexer: *lexer}
+	state.lexer.interpolations = append([]int(nil), lexer.interpolations...)
+
+	return state
+}
+
+// Restore rewinds the lexer to a position returned by Save.
+func (lexer *Lexer) Restore(state State) {
+	*lexer = state.lexer
+	lexer.interpolations = append([]int(nil), state.lexer.interpolations...)
+}
+
 func (lexer *Lexer) NextToken() token.Token {
 
 	//read char and skip whitespace
 	lexer.readChar()
 	lexer.skipWhiteSpace()
 
+	start := lexer.offset
+	column := lexer.column
+
+	tok := lexer.readToken()
+	tok.File = lexer.file
+	tok.Column = column
+	tok.Start = start
+	tok.End = lexer.position
+	tok.EndLine = lexer.line
+	tok.EndColumn = lexer.next
+
+	return tok
+}
+
+// readToken reads the token starting at the current char, leaving char on
+// the token's last rune.
+func (lexer *Lexer) readToken() token.Token {
+
 	//switch on char and return token
 
 	switch lexer.char {
 	case 0:
 		return token.New(token.EOF, "EOF", lexer.line)
+	// arithmetic and compound assignment
 	case '+':
+		if lexer.peekChar() == '=' {
+			lexer.readChar()
+			return token.New(token.PLUS_ASSIGN, string("+="), lexer.line)
+		}
 		return token.New(token.PLUS, string(lexer.char), lexer.line)
 	case '-':
+		if lexer.peekChar() == '=' {
+			lexer.readChar()
+			return token.New(token.MINUS_ASSIGN, string("-="), lexer.line)
+		}
 		return token.New(token.MINUS, string(lexer.char), lexer.line)
 	case '*':
+		if lexer.peekChar() == '*' {
+			lexer.readChar()
+			return token.New(token.POWER, string("**"), lexer.line)
+		}
+		if lexer.peekChar() == '=' {
+			lexer.readChar()
+			return token.New(token.ASTERISK_ASSIGN, string("*="), lexer.line)
+		}
 		return token.New(token.ASTERISK, string(lexer.char), lexer.line)
 	case '%':
+		if lexer.peekChar() == '=' {
+			lexer.readChar()
+			return token.New(token.MODULO_ASSIGN, string("%="), lexer.line)
+		}
 		return token.New(token.MODULO, string(lexer.char), lexer.line)
 
-	// SLASH, COMMENT
+	// SLASH, SLASH_ASSIGN, COMMENT, DOC_COMMENT
 	case '/':
 		if lexer.peekChar() == '/' {
 			return lexer.readComment()
+		} else if lexer.peekChar() == '*' {
+			return lexer.readBlockComment()
+		} else if lexer.peekChar() == '=' {
+			lexer.readChar()
+			return token.New(token.SLASH_ASSIGN, string("/="), lexer.line)
 		}
 		return token.New(token.SLASH, string(lexer.char), lexer.line)
 	// Assign, EQ
@@ -66,7 +146,7 @@ func (lexer *Lexer) NextToken() token.Token {
 		}
 		return token.New(token.BANG, string(lexer.char), lexer.line)
 
-	// VAR, CONST
+	// VAR, CONST, COLON
 	case ':':
 		if lexer.peekChar() == ':' {
 			lexer.readChar()
@@ -75,11 +155,52 @@ func (lexer *Lexer) NextToken() token.Token {
 			lexer.readChar()
 			return token.New(token.VAR, string(":="), lexer.line)
 		}
-		return token.New(token.ILLEGAL, string(lexer.char), lexer.line)
+		return token.New(token.COLON, string(lexer.char), lexer.line)
 
+	// QUESTION, COALESCE
+	case '?':
+		if lexer.peekChar() == '?' {
+			lexer.readChar()
+			return token.New(token.COALESCE, string("??"), lexer.line)
+		}
+		return token.New(token.QUESTION, string(lexer.char), lexer.line)
+
+	// AND, OR, BIT_AND, BIT_OR, BIT_XOR, BIT_NOT
+	case '&':
+		if lexer.peekChar() == '&' {
+			lexer.readChar()
+			return token.New(token.AND, string("&&"), lexer.line)
+		}
+		return token.New(token.BIT_AND, string(lexer.char), lexer.line)
+	case '|':
+		if lexer.peekChar() == '|' {
+			lexer.readChar()
+			return token.New(token.OR, string("||"), lexer.line)
+		}
+		return token.New(token.BIT_OR, string(lexer.char), lexer.line)
+	case '^':
+		return token.New(token.BIT_XOR, string(lexer.char), lexer.line)
+	case '~':
+		return token.New(token.BIT_NOT, string(lexer.char), lexer.line)
+
+	// LT, LT_EQ, SHIFT_LEFT, GT, GT_EQ, SHIFT_RIGHT
 	case '<':
+		if lexer.peekChar() == '<' {
+			lexer.readChar()
+			return token.New(token.SHIFT_LEFT, string("<<"), lexer.line)
+		} else if lexer.peekChar() == '=' {
+			lexer.readChar()
+			return token.New(token.LT_EQ, string("<="), lexer.line)
+		}
 		return token.New(token.LT, string(lexer.char), lexer.line)
 	case '>':
+		if lexer.peekChar() == '>' {
+			lexer.readChar()
+			return token.New(token.SHIFT_RIGHT, string(">>"), lexer.line)
+		} else if lexer.peekChar() == '=' {
+			lexer.readChar()
+			return token.New(token.GT_EQ, string(">="), lexer.line)
+		}
 		return token.New(token.GT, string(lexer.char), lexer.line)
 	case ',':
 		return token.New(token.COMMA, string(lexer.char), lexer.line)
@@ -90,8 +211,19 @@ func (lexer *Lexer) NextToken() token.Token {
 	case ')':
 		return token.New(token.RPAREN, string(lexer.char), lexer.line)
 	case '{':
+		if depth := len(lexer.interpolations); depth > 0 {
+			lexer.interpolations[depth-1] += 1
+		}
 		return token.New(token.LBRACE, string(lexer.char), lexer.line)
 	case '}':
+		if depth := len(lexer.interpolations); depth > 0 {
+			// a } closing ${ resumes the enclosing string
+			if lexer.interpolations[depth-1] == 0 {
+				lexer.interpolations = lexer.interpolations[:depth-1]
+				return lexer.readString(true)
+			}
+			lexer.interpolations[depth-1] -= 1
+		}
 		return token.New(token.RBRACE, string(lexer.char), lexer.line)
 	case '[':
 		return token.New(token.LBRACKET, string(lexer.char), lexer.line)
@@ -99,15 +231,17 @@ func (lexer *Lexer) NextToken() token.Token {
 		return token.New(token.RBRACKET, string(lexer.char), lexer.line)
 		// Strings
 	case '"':
-		return lexer.readString()
+		return lexer.readString(false)
+	case '`':
+		return lexer.readRawString()
 
 	default:
 		// Identifiers
-		if unicode.IsLetter(lexer.char) {
+		if unicode.IsLetter(lexer.char) || lexer.char == '_' {
 			return lexer.readIdentifier()
 		}
 		// Numbers
-		if unicode.IsNumber(lexer.char) {
+		if isDecimalDigit(lexer.char) {
 			return lexer.readNumber()
 		}
 		// Illegal
@@ -125,9 +259,31 @@ func (lexer *Lexer) peekChar() rune {
 	return 0
 }
 
+// peekCharAt returns the rune n positions after the one returned by
+// peekChar, so peekCharAt(0) == peekChar().
+func (lexer *Lexer) peekCharAt(n int) rune {
+	position := lexer.position
+
+	for ; n > 0; n-- {
+		_, s := utf8.DecodeRuneInString(lexer.source[position:])
+		position += s
+	}
+
+	r, _ := utf8.DecodeRuneInString(lexer.source[position:])
+
+	if r != utf8.RuneError {
+		return r
+	}
+
+	return 0
+}
+
 func (lexer *Lexer) readChar() {
 	r, s := utf8.DecodeRuneInString(lexer.source[lexer.position:])
 
+	lexer.offset = lexer.position
+	lexer.column = lexer.next
+
 	switch r {
 	case utf8.RuneError:
 		lexer.char = 0
@@ -135,13 +291,20 @@ func (lexer *Lexer) readChar() {
 	default:
 		lexer.char = r
 		lexer.position += s
+		lexer.next += 1
 	}
 }
 
+// newLine is called with char on a '\n' to start counting a new line.
+func (lexer *Lexer) newLine() {
+	lexer.line += 1
+	lexer.next = 1
+}
+
 func (lexer *Lexer) skipWhiteSpace() {
 	for checkWhiteSpace(lexer.char) {
 		if lexer.char == '\n' {
-			lexer.line += 1
+			lexer.newLine()
 		}
 		lexer.readChar()
 	}
@@ -151,43 +314,208 @@ func checkWhiteSpace(c rune) bool {
 	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
 }
 
+// readComment reads a // line comment. Exactly three slashes make it a
+// DOC_COMMENT, which documents the declaration that follows it.
 func (lexer *Lexer) readComment() token.Token {
 	var comment []rune
 	comment = append(comment, lexer.char)
+	tokenType := token.TokenType(token.COMMENT)
+
+	if lexer.peekCharAt(1) == '/' && lexer.peekCharAt(2) != '/' {
+		tokenType = token.DOC_COMMENT
+	}
 
 	for {
 		lexer.readChar()
 		comment = append(comment, lexer.char)
 
 		if lexer.peekChar() == '\n' || lexer.peekChar() == 0 {
-			return token.New(token.COMMENT, string(comment), lexer.line)
+			return token.New(tokenType, string(comment), lexer.line)
+		}
+	}
+}
+
+// readBlockComment reads a /* */ comment. Block comments nest, so a
+// commented-out region may itself contain block comments. The token carries
+// the line the comment starts on.
+func (lexer *Lexer) readBlockComment() token.Token {
+	line := lexer.line
+	var comment []rune
+	comment = append(comment, lexer.char)
+
+	lexer.readChar()
+	comment = append(comment, lexer.char)
+	depth := 1
+
+	for {
+		if lexer.peekChar() == 0 {
+			return token.New(token.ILLEGAL, "unterminated block comment", line)
+		}
+
+		lexer.readChar()
+		comment = append(comment, lexer.char)
+
+		switch {
+		case lexer.char == '\n':
+			lexer.newLine()
+		case lexer.char == '/' && lexer.peekChar() == '*':
+			lexer.readChar()
+			comment = append(comment, lexer.char)
+			depth += 1
+		case lexer.char == '*' && lexer.peekChar() == '/':
+			lexer.readChar()
+			comment = append(comment, lexer.char)
+			depth -= 1
+
+			if depth == 0 {
+				return token.New(token.COMMENT, string(comment), line)
+			}
 		}
 	}
 }
 
-func (lexer *Lexer) readString() token.Token {
+// readString reads a double quoted string and returns its unquoted, unescaped
+// value. Strings may span lines; the token carries the line it starts on.
+// A string containing ${ is split into a STRING_START token, the tokens of
+// the embedded expression, and STRING_MIDDLE or STRING_END tokens for the
+// text following each closing }.
+func (lexer *Lexer) readString(resumed bool) token.Token {
+	line := lexer.line
 	var str []rune
-	str = append(str, lexer.char)
+	illegal := ""
 
 	for {
 		lexer.readChar()
-		str = append(str, lexer.char)
 
-		if lexer.char == '"' {
-			return token.New(token.STRING, string(str), lexer.line)
+		switch lexer.char {
+		case 0:
+			return token.New(token.ILLEGAL, "unterminated string", line)
+		case '"':
+			if illegal != "" {
+				return token.New(token.ILLEGAL, illegal, line)
+			}
+			if resumed {
+				return token.New(token.STRING_END, string(str), line)
+			}
+			return token.New(token.STRING, string(str), line)
+		case '$':
+			if lexer.peekChar() != '{' {
+				str = append(str, lexer.char)
+				continue
+			}
+
+			lexer.readChar()
+			lexer.interpolations = append(lexer.interpolations, 0)
+
+			if illegal != "" {
+				return token.New(token.ILLEGAL, illegal, line)
+			}
+			if resumed {
+				return token.New(token.STRING_MIDDLE, string(str), line)
+			}
+			return token.New(token.STRING_START, string(str), line)
+		case '\n':
+			lexer.newLine()
+			str = append(str, lexer.char)
+		case '\\':
+			r, err := lexer.readEscape()
+
+			// keep reading up to the closing quote so lexing can continue
+			if err != "" && illegal == "" {
+				illegal = err
+			}
+			str = append(str, r)
+		default:
+			str = append(str, lexer.char)
 		}
-		if lexer.peekChar() == 0 {
-			return token.New(token.ILLEGAL, "ILLEGAL", lexer.line)
+	}
+}
+
+// readEscape reads the escape sequence following a backslash and returns the
+// rune it stands for, or an error message for invalid sequences.
+func (lexer *Lexer) readEscape() (rune, string) {
+	lexer.readChar()
+
+	switch lexer.char {
+	case 'n':
+		return '\n', ""
+	case 't':
+		return '\t', ""
+	case '"':
+		return '"', ""
+	case '$':
+		return '$', ""
+	case '\\':
+		return '\\', ""
+	case 'u':
+		return lexer.readUnicodeEscape()
+	case 0:
+		return 0, "unterminated string"
+	case '\n':
+		lexer.newLine()
+		return 0, "invalid escape sequence: \\ followed by newline"
+	default:
+		return 0, fmt.Sprintf("invalid escape sequence: \\%c", lexer.char)
+	}
+}
+
+// readUnicodeEscape reads the {XXXX} part of a \u{XXXX} escape.
+func (lexer *Lexer) readUnicodeEscape() (rune, string) {
+	if lexer.peekChar() != '{' {
+		return 0, "invalid escape sequence: \\u must be followed by {"
+	}
+	lexer.readChar()
+
+	var digits []rune
+	for lexer.peekChar() != '}' {
+		if lexer.peekChar() == '"' || lexer.peekChar() == 0 {
+			return 0, "invalid escape sequence: unclosed \\u{"
 		}
+		lexer.readChar()
+		digits = append(digits, lexer.char)
+	}
+	lexer.readChar()
+
+	value, err := strconv.ParseUint(string(digits), 16, 32)
+
+	if err != nil || len(digits) > 6 {
+		return 0, fmt.Sprintf("invalid escape sequence: \\u{%s}", string(digits))
 	}
 
+	if !utf8.ValidRune(rune(value)) {
+		return 0, fmt.Sprintf("invalid escape sequence: \\u{%s} is not a valid code point", string(digits))
+	}
+
+	return rune(value), ""
+}
+
+// readRawString reads a backtick delimited string. Raw strings have no
+// escape sequences and may span lines.
+func (lexer *Lexer) readRawString() token.Token {
+	line := lexer.line
+	var str []rune
+
+	for {
+		lexer.readChar()
+
+		switch lexer.char {
+		case 0:
+			return token.New(token.ILLEGAL, "unterminated raw string", line)
+		case '`':
+			return token.New(token.STRING, string(str), line)
+		case '\n':
+			lexer.newLine()
+		}
+
+		str = append(str, lexer.char)
+	}
 }
 
 func (lexer *Lexer) readIdentifier() token.Token {
 	var ident []rune
 	ident = append(ident, lexer.char)
 
-	for unicode.IsLetter(lexer.peekChar()) {
+	for isIdentifierRune(lexer.peekChar()) {
 		lexer.readChar()
 		ident = append(ident, lexer.char)
 	}
@@ -197,13 +525,128 @@ func (lexer *Lexer) readIdentifier() token.Token {
 	return token.New(tokenType, string(ident), lexer.line)
 }
 
+func isIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+type numberBase struct {
+	name    string
+	isDigit func(rune) bool
+}
+
+var decimalBase = numberBase{"decimal", isDecimalDigit}
+
+// prefixedBases is keyed by the lower-cased letter after a leading 0.
+var prefixedBases = map[rune]numberBase{
+	'x': {"hexadecimal", isHexDigit},
+	'o': {"octal", isOctalDigit},
+	'b': {"binary", isBinaryDigit},
+}
+
+func isDecimalDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+func isHexDigit(r rune) bool {
+	return isDecimalDigit(r) || ('a' <= r && r <= 'f') || ('A' <= r && r <= 'F')
+}
+
+func isOctalDigit(r rune) bool {
+	return '0' <= r && r <= '7'
+}
+
+func isBinaryDigit(r rune) bool {
+	return r == '0' || r == '1'
+}
+
+// readNumber reads decimal integers and floats as well as 0x, 0o and 0b
+// integers, all of which may use _ between digits. The token keeps the
+// literal as written; the parser converts it.
 func (lexer *Lexer) readNumber() token.Token {
 	var number []rune
 	number = append(number, lexer.char)
+	tokenType := token.TokenType(token.NUMBER)
+	base := decimalBase
+	prefixed := false
+
+	if prefixedBase, ok := prefixedBases[unicode.ToLower(lexer.peekChar())]; ok && lexer.char == '0' {
+		base = prefixedBase
+		prefixed = true
+		lexer.readChar()
+		number = append(number, lexer.char)
+		number = append(number, lexer.readDigits(base.isDigit)...)
+	} else {
+		number = append(number, lexer.readDigits(isDecimalDigit)...)
+
+		// fraction, only when a digit follows the '.'
+		if lexer.peekChar() == '.' && isDecimalDigit(lexer.peekCharAt(1)) {
+			tokenType = token.FLOAT
+			lexer.readChar()
+			number = append(number, lexer.char)
+			number = append(number, lexer.readDigits(isDecimalDigit)...)
+		}
+
+		// exponent, only when digits (optionally signed) follow the 'e'
+		if lexer.peekChar() == 'e' || lexer.peekChar() == 'E' {
+			next := lexer.peekCharAt(1)
+			signed := next == '+' || next == '-'
+
+			if isDecimalDigit(next) || (signed && isDecimalDigit(lexer.peekCharAt(2))) {
+				tokenType = token.FLOAT
+				lexer.readChar()
+				number = append(number, lexer.char)
+
+				if signed {
+					lexer.readChar()
+					number = append(number, lexer.char)
+				}
 
-	for unicode.IsNumber(lexer.peekChar()) {
+				number = append(number, lexer.readDigits(isDecimalDigit)...)
+			}
+		}
+	}
+
+	if next := lexer.peekChar(); isIdentifierRune(next) {
+		name := base.name
+		if tokenType == token.FLOAT {
+			name = "float"
+		}
+
+		return lexer.illegalNumber(number, fmt.Sprintf("invalid character %q in %s literal", next, name))
+	}
+
+	if prefixed && len(number) == 2 {
+		return lexer.illegalNumber(number, fmt.Sprintf("missing %s digits", base.name))
+	}
+
+	for i, r := range number {
+		if r == '_' && (i == len(number)-1 || !base.isDigit(number[i-1]) || !base.isDigit(number[i+1])) {
+			return lexer.illegalNumber(number, "_ must separate digits")
+		}
+	}
+
+	return token.New(tokenType, string(number), lexer.line)
+}
+
+// illegalNumber swallows the rest of a malformed literal so that it is
+// reported once rather than as an ILLEGAL token followed by an identifier.
+func (lexer *Lexer) illegalNumber(number []rune, reason string) token.Token {
+	for isIdentifierRune(lexer.peekChar()) {
 		lexer.readChar()
 		number = append(number, lexer.char)
 	}
-	return token.New(token.NUMBER, string(number), lexer.line)
+
+	return token.New(token.ILLEGAL, fmt.Sprintf("invalid number literal %s: %s", string(number), reason), lexer.line)
+}
+
+// readDigits also consumes _ separators; readNumber checks their placement.
+func (lexer *Lexer) readDigits(isDigit func(rune) bool) []rune {
+	var digits []rune
+
+	for isDigit(lexer.peekChar()) || lexer.peekChar() == '_' {
+		lexer.readChar()
+		digits = append(digits, lexer.char)
+	}
+
+	return digits
 }
@@ -1,12 +1,13 @@
 package lexer
 
 import (
+	"strings"
 	"testing"
 	"ziplang/token"
 )
 
 func TestLexerIllegal(t *testing.T) {
-	input := " 3; ${ # ?"
+	input := " 3; ${ # @"
 
 	tests := []struct {
 		expectedType  token.TokenType
@@ -18,7 +19,7 @@ func TestLexerIllegal(t *testing.T) {
 		{token.ILLEGAL, "$", 1},
 		{token.LBRACE, "{", 1},
 		{token.ILLEGAL, "#", 1},
-		{token.ILLEGAL, "?", 1},
+		{token.ILLEGAL, "@", 1},
 		{token.EOF, "EOF", 1},
 	}
 
@@ -89,7 +90,7 @@ func TestLexerComment(t *testing.T) {
 }
 
 func TestLexerKeywords(t *testing.T) {
-	input := "true false return fn"
+	input := "true false return fn if else while for break continue"
 
 	tests := []struct {
 		expectedType  token.TokenType
@@ -100,6 +101,12 @@ func TestLexerKeywords(t *testing.T) {
 		{token.FALSE, "false", 1},
 		{token.RETURN, "return", 1},
 		{token.FUNCTION, "fn", 1},
+		{token.IF, "if", 1},
+		{token.ELSE, "else", 1},
+		{token.WHILE, "while", 1},
+		{token.FOR, "for", 1},
+		{token.BREAK, "break", 1},
+		{token.CONTINUE, "continue", 1},
 		{token.EOF, "EOF", 1},
 	}
 
@@ -235,7 +242,7 @@ func TestLexerParen(t *testing.T) {
 }
 
 func TestLexerDelimiters(t *testing.T) {
-	input := ",;,,;;"
+	input := ",;,,;;: :"
 
 	tests := []struct {
 		expectedType  token.TokenType
@@ -248,6 +255,8 @@ func TestLexerDelimiters(t *testing.T) {
 		{token.COMMA, ",", 1},
 		{token.SEMICOLON, ";", 1},
 		{token.SEMICOLON, ";", 1},
+		{token.COLON, ":", 1},
+		{token.COLON, ":", 1},
 		{token.EOF, "EOF", 1},
 	}
 
@@ -271,7 +280,7 @@ func TestLexerDelimiters(t *testing.T) {
 }
 
 func TestLexerOperators(t *testing.T) {
-	input := "+-*%/=!:::=<>==!="
+	input := "+-*%/ =!:::=< > ==!= <= >= += -= *= /= %="
 
 	tests := []struct {
 		expectedType  token.TokenType
@@ -291,6 +300,13 @@ func TestLexerOperators(t *testing.T) {
 		{token.GT, ">", 1},
 		{token.EQ, "==", 1},
 		{token.NOT_EQ, "!=", 1},
+		{token.LT_EQ, "<=", 1},
+		{token.GT_EQ, ">=", 1},
+		{token.PLUS_ASSIGN, "+=", 1},
+		{token.MINUS_ASSIGN, "-=", 1},
+		{token.ASTERISK_ASSIGN, "*=", 1},
+		{token.SLASH_ASSIGN, "/=", 1},
+		{token.MODULO_ASSIGN, "%=", 1},
 		{token.EOF, "EOF", 1},
 	}
 
@@ -314,7 +330,7 @@ func TestLexerOperators(t *testing.T) {
 }
 
 func TestNextTokenGeneric(t *testing.T) {
-	input := "  +  - */=   \n < > \n , ; \n ( )  \n test öra 13 004öra { } \n  [] \n  \"hejhej\" \n ! != == // test-comment \n :: :=  % false true return fn"
+	input := "  +  - */ =   \n < > \n , ; \n ( )  \n test öra 13 004öra { } \n  [] \n  \"hejhej\" \n ! != == // test-comment \n :: :=  % false true return fn"
 
 	tests := []struct {
 		expectedType  token.TokenType
@@ -335,13 +351,12 @@ func TestNextTokenGeneric(t *testing.T) {
 		{token.IDENTIFIER, "test", 5},
 		{token.IDENTIFIER, "öra", 5},
 		{token.NUMBER, "13", 5},
-		{token.NUMBER, "004", 5},
-		{token.IDENTIFIER, "öra", 5},
+		{token.ILLEGAL, "invalid number literal 004öra: invalid character 'ö' in decimal literal", 5},
 		{token.LBRACE, "{", 5},
 		{token.RBRACE, "}", 5},
 		{token.LBRACKET, "[", 6},
 		{token.RBRACKET, "]", 6},
-		{token.STRING, "\"hejhej\"", 7},
+		{token.STRING, "hejhej", 7},
 		{token.BANG, "!", 8},
 		{token.NOT_EQ, "!=", 8},
 		{token.EQ, "==", 8},
@@ -374,3 +389,560 @@ func TestNextTokenGeneric(t *testing.T) {
 		}
 	}
 }
+
+func TestLexerFloat(t *testing.T) {
+	input := "3.14 1e5 2.5E-3 7e+2 1. 4e x1.5 2.a"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.FLOAT, "3.14", 1},
+		{token.FLOAT, "1e5", 1},
+		{token.FLOAT, "2.5E-3", 1},
+		{token.FLOAT, "7e+2", 1},
+		{token.NUMBER, "1", 1},
+		{token.ILLEGAL, ".", 1},
+		{token.ILLEGAL, "invalid number literal 4e: invalid character 'e' in decimal literal", 1},
+		{token.IDENTIFIER, "x1", 1},
+		{token.ILLEGAL, ".", 1},
+		{token.NUMBER, "5", 1},
+		{token.NUMBER, "2", 1},
+		{token.ILLEGAL, ".", 1},
+		{token.IDENTIFIER, "a", 1},
+		{token.EOF, "EOF", 1},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerString(t *testing.T) {
+	input := `"plain" "esc\n\t\"\\" "\u{41}\u{10FFFF}" "two
+lines" 1 ` + "`raw\\n\n${x}`" + ` 2 "bad\q" 3 "\u{110000}" "\u{zz}" "\u41" 4 "open`
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.STRING, "plain", 1},
+		{token.STRING, "esc\n\t\"\\", 1},
+		{token.STRING, "A\U0010FFFF", 1},
+		{token.STRING, "two\nlines", 1},
+		{token.NUMBER, "1", 2},
+		{token.STRING, "raw\\n\n${x}", 2},
+		{token.NUMBER, "2", 3},
+		{token.ILLEGAL, "invalid escape sequence: \\q", 3},
+		{token.NUMBER, "3", 3},
+		{token.ILLEGAL, "invalid escape sequence: \\u{110000} is not a valid code point", 3},
+		{token.ILLEGAL, "invalid escape sequence: \\u{zz}", 3},
+		{token.ILLEGAL, "invalid escape sequence: \\u must be followed by {", 3},
+		{token.NUMBER, "4", 3},
+		{token.ILLEGAL, "unterminated string", 3},
+		{token.EOF, "EOF", 3},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerUnterminatedRawString(t *testing.T) {
+	l := New("`never\nclosed")
+
+	tok := l.NextToken()
+
+	if tok.Type != token.ILLEGAL || tok.Value != "unterminated raw string" || tok.Line != 1 {
+		t.Fatalf("wrong token for unterminated raw string. got=%+v", tok)
+	}
+
+	tok = l.NextToken()
+
+	if tok.Type != token.EOF || tok.Line != 2 {
+		t.Fatalf("wrong token after unterminated raw string. got=%+v", tok)
+	}
+}
+
+func TestLexerInterpolatedString(t *testing.T) {
+	input := `"a ${x} b ${ {1: 2}[1] } c" "${"in ${y}"}"
+"line ${
+z
+} end"`
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.STRING_START, "a ", 1},
+		{token.IDENTIFIER, "x", 1},
+		{token.STRING_MIDDLE, " b ", 1},
+		{token.LBRACE, "{", 1},
+		{token.NUMBER, "1", 1},
+		{token.COLON, ":", 1},
+		{token.NUMBER, "2", 1},
+		{token.RBRACE, "}", 1},
+		{token.LBRACKET, "[", 1},
+		{token.NUMBER, "1", 1},
+		{token.RBRACKET, "]", 1},
+		{token.STRING_END, " c", 1},
+		{token.STRING_START, "", 1},
+		{token.STRING_START, "in ", 1},
+		{token.IDENTIFIER, "y", 1},
+		{token.STRING_END, "", 1},
+		{token.STRING_END, "", 1},
+		{token.STRING_START, "line ", 2},
+		{token.IDENTIFIER, "z", 3},
+		{token.STRING_END, " end", 4},
+		{token.EOF, "EOF", 4},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerLogicalOperators(t *testing.T) {
+	input := "a && b || c & |"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.IDENTIFIER, "a", 1},
+		{token.AND, "&&", 1},
+		{token.IDENTIFIER, "b", 1},
+		{token.OR, "||", 1},
+		{token.IDENTIFIER, "c", 1},
+		{token.BIT_AND, "&", 1},
+		{token.BIT_OR, "|", 1},
+		{token.EOF, "EOF", 1},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerBitwiseOperators(t *testing.T) {
+	input := "a & b | c ^ ~d << 2 >> 1 < e > f"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.IDENTIFIER, "a", 1},
+		{token.BIT_AND, "&", 1},
+		{token.IDENTIFIER, "b", 1},
+		{token.BIT_OR, "|", 1},
+		{token.IDENTIFIER, "c", 1},
+		{token.BIT_XOR, "^", 1},
+		{token.BIT_NOT, "~", 1},
+		{token.IDENTIFIER, "d", 1},
+		{token.SHIFT_LEFT, "<<", 1},
+		{token.NUMBER, "2", 1},
+		{token.SHIFT_RIGHT, ">>", 1},
+		{token.NUMBER, "1", 1},
+		{token.LT, "<", 1},
+		{token.IDENTIFIER, "e", 1},
+		{token.GT, ">", 1},
+		{token.IDENTIFIER, "f", 1},
+		{token.EOF, "EOF", 1},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerPowerOperator(t *testing.T) {
+	input := "a ** b * c *= d"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.IDENTIFIER, "a", 1},
+		{token.POWER, "**", 1},
+		{token.IDENTIFIER, "b", 1},
+		{token.ASTERISK, "*", 1},
+		{token.IDENTIFIER, "c", 1},
+		{token.ASTERISK_ASSIGN, "*=", 1},
+		{token.IDENTIFIER, "d", 1},
+		{token.EOF, "EOF", 1},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerConditionalOperators(t *testing.T) {
+	input := "a ? b : c ?? d :: e := f"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.IDENTIFIER, "a", 1},
+		{token.QUESTION, "?", 1},
+		{token.IDENTIFIER, "b", 1},
+		{token.COLON, ":", 1},
+		{token.IDENTIFIER, "c", 1},
+		{token.COALESCE, "??", 1},
+		{token.IDENTIFIER, "d", 1},
+		{token.CONST, "::", 1},
+		{token.IDENTIFIER, "e", 1},
+		{token.VAR, ":=", 1},
+		{token.IDENTIFIER, "f", 1},
+		{token.EOF, "EOF", 1},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerIdentifiers(t *testing.T) {
+	input := "user_id x2 _private __ a_1_b fn_ if2 2x"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.IDENTIFIER, "user_id", 1},
+		{token.IDENTIFIER, "x2", 1},
+		{token.IDENTIFIER, "_private", 1},
+		{token.IDENTIFIER, "__", 1},
+		{token.IDENTIFIER, "a_1_b", 1},
+		{token.IDENTIFIER, "fn_", 1},
+		{token.IDENTIFIER, "if2", 1},
+		{token.ILLEGAL, "invalid number literal 2x: invalid character 'x' in decimal literal", 1},
+		{token.EOF, "EOF", 1},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerNumberLiterals(t *testing.T) {
+	input := "0x1F 0XfF 0o17 0b1010 1_000_000 0x_1 0xdead_beef 1_0.5e1_0 007 0 0x 0o 0b2 0xfg 0o78 1__0 1_ 0b1_ 1_.5 12abc 1e 1.5e_3 2.5x 1e5f x"
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.NUMBER, "0x1F", 1},
+		{token.NUMBER, "0XfF", 1},
+		{token.NUMBER, "0o17", 1},
+		{token.NUMBER, "0b1010", 1},
+		{token.NUMBER, "1_000_000", 1},
+		{token.ILLEGAL, "invalid number literal 0x_1: _ must separate digits", 1},
+		{token.NUMBER, "0xdead_beef", 1},
+		{token.FLOAT, "1_0.5e1_0", 1},
+		{token.NUMBER, "007", 1},
+		{token.NUMBER, "0", 1},
+		{token.ILLEGAL, "invalid number literal 0x: missing hexadecimal digits", 1},
+		{token.ILLEGAL, "invalid number literal 0o: missing octal digits", 1},
+		{token.ILLEGAL, "invalid number literal 0b2: invalid character '2' in binary literal", 1},
+		{token.ILLEGAL, "invalid number literal 0xfg: invalid character 'g' in hexadecimal literal", 1},
+		{token.ILLEGAL, "invalid number literal 0o78: invalid character '8' in octal literal", 1},
+		{token.ILLEGAL, "invalid number literal 1__0: _ must separate digits", 1},
+		{token.ILLEGAL, "invalid number literal 1_: _ must separate digits", 1},
+		{token.ILLEGAL, "invalid number literal 0b1_: _ must separate digits", 1},
+		{token.ILLEGAL, "invalid number literal 1_.5: _ must separate digits", 1},
+		{token.ILLEGAL, "invalid number literal 12abc: invalid character 'a' in decimal literal", 1},
+		{token.ILLEGAL, "invalid number literal 1e: invalid character 'e' in decimal literal", 1},
+		{token.ILLEGAL, "invalid number literal 1.5e_3: invalid character 'e' in float literal", 1},
+		{token.ILLEGAL, "invalid number literal 2.5x: invalid character 'x' in float literal", 1},
+		{token.ILLEGAL, "invalid number literal 1e5f: invalid character 'f' in float literal", 1},
+		{token.IDENTIFIER, "x", 1},
+		{token.EOF, "EOF", 1},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerBlockAndDocComments(t *testing.T) {
+	input := `1 /* one */ 2 /* outer
+/* inner
+*/ still outer */ 3
+/// doc
+//// not doc
+///
+x /*/ still open */
+/* open /* nested */`
+
+	tests := []struct {
+		expectedType  token.TokenType
+		expectedValue string
+		expectedLine  int
+	}{
+		{token.NUMBER, "1", 1},
+		{token.COMMENT, "/* one */", 1},
+		{token.NUMBER, "2", 1},
+		{token.COMMENT, "/* outer\n/* inner\n*/ still outer */", 1},
+		{token.NUMBER, "3", 3},
+		{token.DOC_COMMENT, "/// doc", 4},
+		{token.COMMENT, "//// not doc", 5},
+		{token.DOC_COMMENT, "///", 6},
+		{token.IDENTIFIER, "x", 7},
+		{token.COMMENT, "/*/ still open */", 7},
+		{token.ILLEGAL, "unterminated block comment", 8},
+		{token.EOF, "EOF", 8},
+	}
+
+	l := New(input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Value != tc.expectedValue {
+			t.Fatalf("tests [%d] - value wrong. expected=%q, got =%q", i, tc.expectedValue, tok.Value)
+		}
+
+		if tok.Line != tc.expectedLine {
+			t.Fatalf("tests [%d] - line wrong. expected=%d, got =%d", i, tc.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestLexerPositions(t *testing.T) {
+	input := "öra := 1;\n  s := \"a\nb\" + `é`; /* x\n */ \"${ab}\"\n"
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+		expectedStart  int
+		expectedEnd    int
+	}{
+		{token.IDENTIFIER, 1, 1, 0, 4},
+		{token.VAR, 1, 5, 5, 7},
+		{token.NUMBER, 1, 8, 8, 9},
+		{token.SEMICOLON, 1, 9, 9, 10},
+		{token.IDENTIFIER, 2, 3, 13, 14},
+		{token.VAR, 2, 5, 15, 17},
+		{token.STRING, 2, 8, 18, 23},
+		{token.PLUS, 3, 4, 24, 25},
+		{token.STRING, 3, 6, 26, 30},
+		{token.SEMICOLON, 3, 9, 30, 31},
+		{token.COMMENT, 3, 11, 32, 40},
+		{token.STRING_START, 4, 5, 41, 44},
+		{token.IDENTIFIER, 4, 8, 44, 46},
+		{token.STRING_END, 4, 10, 46, 48},
+		{token.EOF, 5, 1, 49, 49},
+	}
+
+	l := NewFile("main.zip", input)
+
+	for i, tc := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Line != tc.expectedLine || tok.Column != tc.expectedColumn {
+			t.Fatalf("tests [%d] - position wrong. expected=%d:%d, got =%d:%d", i, tc.expectedLine, tc.expectedColumn, tok.Line, tok.Column)
+		}
+
+		if tok.Start != tc.expectedStart || tok.End != tc.expectedEnd {
+			t.Fatalf("tests [%d] - offsets wrong. expected=[%d, %d), got =[%d, %d)", i, tc.expectedStart, tc.expectedEnd, tok.Start, tok.End)
+		}
+
+		if tok.File != "main.zip" {
+			t.Fatalf("tests [%d] - file wrong. expected=%q, got =%q", i, "main.zip", tok.File)
+		}
+	}
+}
+
+func TestLexerSaveRestore(t *testing.T) {
+	input := `"a ${ { } } b"`
+
+	l := New(input)
+	l.NextToken() // STRING_START
+	l.NextToken() // {
+
+	saved := l.Save()
+
+	// reading past the interpolation must not leak into the restored state
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	l.Restore(saved)
+
+	expected := []token.TokenType{token.RBRACE, token.STRING_END, token.EOF}
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+
+		if tok.Type != tt {
+			t.Fatalf("tests [%d] - tokentype wrong. expected=%q, got =%q", i, tt, tok.Type)
+		}
+	}
+}
+
+func TestLexerLongLine(t *testing.T) {
+	var input strings.Builder
+
+	input.WriteString("x := [")
+	for i := 0; i < 50000; i++ {
+		input.WriteString("ö, ")
+	}
+	input.WriteString("1]")
+
+	l := New(input.String())
+
+	var last token.Token
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		last = tok
+	}
+
+	if last.Type != token.RBRACKET || last.Column != 6+50000*3+2 {
+		t.Errorf("wrong last token. got=%s at column %d", last.Type, last.Column)
+	}
+}
@@ -1,32 +1,88 @@
 package object
 
+import (
+	"fmt"
+)
+
+type binding struct {
+	value    Object
+	constant bool
+}
+
 type Environment struct {
-	store map[string]Object
+	store map[string]binding
 	outer *Environment
 }
 
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
+	s := make(map[string]binding)
 	return &Environment{
 		store: s,
 		outer: nil,
 	}
 }
 
+// Set binds name to val in this scope as a mutable variable, overwriting any
+// existing binding. It performs no declaration checks.
 func (e *Environment) Set(name string, val Object) Object {
-	e.store[name] = val
+	e.store[name] = binding{value: val}
 
 	return val
 }
 
+// Declare introduces a new binding in this scope. It fails if name is
+// already declared in this scope; bindings in outer scopes are shadowed.
+func (e *Environment) Declare(name string, val Object, constant bool) (Object, *Error) {
+	if _, ok := e.store[name]; ok {
+		return nil, &Error{Code: AlreadyDeclared, Message: fmt.Sprintf("identifier already declared: %s", name)}
+	}
+
+	e.store[name] = binding{value: val, constant: constant}
+
+	return val, nil
+}
+
+// Assign updates an existing binding in the nearest scope that declares
+// name. It fails if name is undeclared or bound as a constant.
+func (e *Environment) Assign(name string, val Object) (Object, *Error) {
+	b, ok := e.store[name]
+
+	if !ok {
+		if e.outer != nil {
+			return e.outer.Assign(name, val)
+		}
+		return nil, &Error{Code: NotDeclared, Message: fmt.Sprintf("identifier not declared: %s", name)}
+	}
+
+	if b.constant {
+		return nil, &Error{Code: ConstantAssignment, Message: fmt.Sprintf("cannot assign to constant: %s", name)}
+	}
+
+	b.value = val
+	e.store[name] = b
+
+	return val, nil
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
+	b, ok := e.store[name]
+
+	if !ok && e.outer != nil {
+		return e.outer.Get(name)
+	}
+
+	return b.value, ok
+}
+
+// IsConstant reports whether name resolves to a constant binding.
+func (e *Environment) IsConstant(name string) bool {
+	b, ok := e.store[name]
 
 	if !ok && e.outer != nil {
-		obj, ok = e.outer.Get(name)
+		return e.outer.IsConstant(name)
 	}
 
-	return obj, ok
+	return ok && b.constant
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
@@ -0,0 +1,124 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// HashKey identifies a hashable value. Value is the exact value rather than
+// a hash of it, so two distinct keys can never collide. Values of different
+// types never share a key, so 1 and true are distinct hash keys.
+type HashKey struct {
+	Type  ObjectType
+	Value string
+}
+
+// Hashable is implemented by objects that can be used as hash keys.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+// HashKey uses the decimal digits, which are the same for either
+// representation because a Number is only big when it does not fit in an int.
+func (n *Number) HashKey() HashKey {
+	return HashKey{Type: n.Type(), Value: n.ToString()}
+}
+
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Value: s.Value}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	return HashKey{Type: b.Type(), Value: b.ToString()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash is a dictionary that remembers insertion order, so iterating over its
+// keys is deterministic. Updating an existing key keeps its position.
+type Hash struct {
+	pairs map[HashKey]HashPair
+	order []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{
+		pairs: make(map[HashKey]HashPair),
+		order: []HashKey{},
+	}
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJ
+}
+
+func (h *Hash) ToString() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.Pairs() {
+		pairs = append(pairs, inspect(pair.Key)+": "+inspect(pair.Value))
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (h *Hash) Set(key Hashable, val Object) {
+	hashKey := key.HashKey()
+
+	if _, ok := h.pairs[hashKey]; !ok {
+		h.order = append(h.order, hashKey)
+	}
+
+	h.pairs[hashKey] = HashPair{Key: key, Value: val}
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.pairs[key.HashKey()]
+
+	return pair.Value, ok
+}
+
+// Delete removes key and returns the value it held.
+func (h *Hash) Delete(key Hashable) (Object, bool) {
+	hashKey := key.HashKey()
+	pair, ok := h.pairs[hashKey]
+
+	if !ok {
+		return nil, false
+	}
+
+	delete(h.pairs, hashKey)
+
+	for i, k := range h.order {
+		if k == hashKey {
+			h.order = append(h.order[:i], h.order[i+1:]...)
+			break
+		}
+	}
+
+	return pair.Value, true
+}
+
+func (h *Hash) Len() int {
+	return len(h.order)
+}
+
+// Pairs returns the key/value pairs in insertion order.
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.order))
+
+	for _, k := range h.order {
+		pairs = append(pairs, h.pairs[k])
+	}
+
+	return pairs
+}
@@ -1,13 +1,26 @@
 package object
 
 import (
+	"bytes"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
+	"ziplang/ast"
 )
 
 const (
 	NUMBER_OBJ       = "NUMBER"
+	FLOAT_OBJ        = "FLOAT"
+	STRING_OBJ       = "STRING"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 	ERROR_OBJ        = "ERROR"
+	FUNCTION_OBJ     = "FUNCTION"
+	BUILTIN_OBJ      = "BUILTIN"
+	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	BOOLEAN_OBJ      = "BOOLEAN"
   PREFIX_OBJ       = "PREFIX"
   NULL_OBJ         = "NULL"
@@ -20,8 +33,21 @@ type Object interface {
 	ToString() string
 }
 
+// Number is an integer of arbitrary size. Values that fit in an int are kept
+// in Value with Big nil; larger values are kept in Big and Value is unused.
+// Use NewNumber to get this normalized form from a big.Int.
 type Number struct {
 	Value int
+	Big   *big.Int
+}
+
+// NewNumber returns value as a Number, demoting it to an int when it fits.
+func NewNumber(value *big.Int) *Number {
+	if value.IsInt64() && value.Int64() >= math.MinInt && value.Int64() <= math.MaxInt {
+		return &Number{Value: int(value.Int64())}
+	}
+
+	return &Number{Big: value}
 }
 
 func (n *Number) Type() ObjectType {
@@ -29,15 +55,67 @@ func (n *Number) Type() ObjectType {
 }
 
 func (n *Number) ToString() string {
+	if n.Big != nil {
+		return n.Big.String()
+	}
+
 	return strconv.Itoa(n.Value)
 }
 
+// IsBig reports whether the number does not fit in an int.
+func (n *Number) IsBig() bool {
+	return n.Big != nil
+}
+
+// BigInt returns the value as a new big.Int that the caller may modify.
+func (n *Number) BigInt() *big.Int {
+	if n.Big != nil {
+		return new(big.Int).Set(n.Big)
+	}
+
+	return big.NewInt(int64(n.Value))
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the number.
+func (n *Number) Sign() int {
+	switch {
+	case n.Big != nil:
+		return n.Big.Sign()
+	case n.Value < 0:
+		return -1
+	case n.Value > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+// ToString always keeps a fraction or exponent so that floats remain
+// distinguishable from integers when printed.
+func (f *Float) ToString() string {
+	str := strconv.FormatFloat(f.Value, 'g', -1, 64)
+
+	if strings.ContainsAny(str, ".eIN") {
+		return str
+	}
+
+	return str + ".0"
+}
+
 type String struct {
 	Value string
 }
 
 func (s *String) Type() ObjectType {
-	return NUMBER_OBJ
+	return STRING_OBJ
 }
 
 func (s *String) ToString() string {
@@ -56,8 +134,22 @@ func (rv *ReturnValue) ToString() string {
 	return rv.Value.ToString()
 }
 
+// ErrorCode classifies a runtime error so that tools can act on it without
+// matching its message. Most errors have no code.
+type ErrorCode string
+
+const (
+	AlreadyDeclared    ErrorCode = "already-declared"
+	NotDeclared        ErrorCode = "not-declared"
+	ConstantAssignment ErrorCode = "constant-assignment"
+)
+
+// Error is a runtime error. Extent is the innermost node whose evaluation
+// raised it; it is empty for errors created outside of Evaluate.
 type Error struct {
+	Code    ErrorCode
 	Message string
+	Extent  ast.Span
 }
 
 func (e *Error) Type() ObjectType {
@@ -101,3 +193,102 @@ func (n *Null) Type() ObjectType {
 func (n *Null) ToString() string {
   return "null"
 }
+
+// Function is a user-defined function value. It keeps a reference to the
+// environment it was defined in so that calls can resolve closed-over names.
+type Function struct {
+	Parameters  []*ast.IdentifierExpression
+	Body        *ast.BlockStatement
+	Environment *Environment
+}
+
+func (f *Function) Type() ObjectType {
+	return FUNCTION_OBJ
+}
+
+func (f *Function) ToString() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.Value)
+	}
+
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// BuiltinFunction is the Go signature of a builtin. It receives already
+// evaluated arguments and reports misuse by returning an *Error.
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType {
+	return BUILTIN_OBJ
+}
+
+func (b *Builtin) ToString() string {
+	return "builtin " + b.Name
+}
+
+// Break and Continue signal loop control flow. Like ReturnValue they unwind
+// through blocks until the innermost loop handles them.
+type Break struct{}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+func (b *Break) ToString() string {
+	return "break"
+}
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
+func (c *Continue) ToString() string {
+	return "continue"
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType {
+	return ARRAY_OBJ
+}
+
+func (a *Array) ToString() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range a.Elements {
+		elements = append(elements, inspect(e))
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// inspect renders obj the way it appears inside an array or hash, where
+// strings are quoted so that "1" and 1 can be told apart.
+func inspect(obj Object) string {
+	if str, ok := obj.(*String); ok {
+		return strconv.Quote(str.Value)
+	}
+
+	return obj.ToString()
+}
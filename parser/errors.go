@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"ziplang/token"
+)
+
+// ErrorCode identifies the kind of a ParseError, so that tools can act on
+// errors without matching their messages.
+type ErrorCode string
+
+const (
+	UnexpectedToken ErrorCode = "unexpected-token" // a specific token was expected
+	NoPrefix        ErrorCode = "no-prefix"        // the token cannot start an expression
+	IllegalToken    ErrorCode = "illegal-token"    // the lexer rejected the source
+	InvalidInteger  ErrorCode = "invalid-integer"
+	InvalidFloat    ErrorCode = "invalid-float"
+)
+
+// ParseError is a single problem found while parsing. Start and End cover
+// the offending token; Expected is only set for UnexpectedToken.
+type ParseError struct {
+	Code     ErrorCode
+	Message  string
+	Start    token.Position
+	End      token.Position
+	Expected token.TokenType
+	Found    token.TokenType
+}
+
+// Error formats the error as file:line:column: message, leaving out the
+// file name for source that did not come from a file.
+func (e ParseError) Error() string {
+	if e.Start.File == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Start.Line, e.Start.Column, e.Message)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s", e.Start.File, e.Start.Line, e.Start.Column, e.Message)
+}
+
+// ErrorList is the error returned by Parser.Err. It holds every ParseError
+// of one parse, in the order they were found.
+type ErrorList []ParseError
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Errors returns the errors found so far, in source order.
+func (p *Parser) Errors() []ParseError {
+	return p.errors
+}
+
+// Err returns an ErrorList when parsing failed and nil otherwise.
+func (p *Parser) Err() error {
+	if len(p.errors) == 0 {
+		return nil
+	}
+
+	return ErrorList(p.errors)
+}
+
+// errorAt records an error covering tok and returns it so that callers can
+// fill in further details. Errors following the first one in a statement
+// are usually caused by it, so they are dropped until the parser has
+// synchronized.
+func (p *Parser) errorAt(tok token.Token, code ErrorCode, format string, args ...interface{}) *ParseError {
+	if p.panicking {
+		return &ParseError{}
+	}
+
+	p.panicking = true
+	p.errors = append(p.errors, ParseError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Start:   tok.Pos(),
+		End:     tok.EndPos(),
+		Found:   tok.Type,
+	})
+
+	return &p.errors[len(p.errors)-1]
+}
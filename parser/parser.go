@@ -1,9 +1,9 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 	"ziplang/ast"
 	"ziplang/lexer"
 	"ziplang/token"
@@ -12,32 +12,82 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	TERNARY
+	COALESCE
+	LOGICALOR
+	LOGICALAND
 	EQUALS
 	LESSGREATER
+	BITOR
+	BITXOR
+	BITAND
+	SHIFT
 	SUM
 	PRODUCT
+	POWER
 	PREFIX
 	CALL
+	INDEX
 )
 
 var precendences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.MODULO:   PRODUCT,
-	token.LPAREN:   CALL,
+	token.QUESTION:    TERNARY,
+	token.COALESCE:    COALESCE,
+	token.OR:          LOGICALOR,
+	token.AND:         LOGICALAND,
+	token.EQ:          EQUALS,
+	token.NOT_EQ:      EQUALS,
+	token.LT:          LESSGREATER,
+	token.GT:          LESSGREATER,
+	token.LT_EQ:       LESSGREATER,
+	token.GT_EQ:       LESSGREATER,
+	token.BIT_OR:      BITOR,
+	token.BIT_XOR:     BITXOR,
+	token.BIT_AND:     BITAND,
+	token.SHIFT_LEFT:  SHIFT,
+	token.SHIFT_RIGHT: SHIFT,
+	token.PLUS:        SUM,
+	token.MINUS:       SUM,
+	token.SLASH:       PRODUCT,
+	token.ASTERISK:    PRODUCT,
+	token.MODULO:      PRODUCT,
+	token.POWER:       POWER,
+	token.LPAREN:      CALL,
+	token.LBRACKET:    INDEX,
+}
+
+// rightAssociative lists the infix operators that group from the right, so
+// a ** b ** c parses as a ** (b ** c).
+var rightAssociative = map[token.TokenType]bool{
+	token.POWER: true,
+}
+
+// nesting is how much each token opens or closes a bracketed construct. The
+// embedded expressions of a string count as bracketed too.
+var nesting = map[token.TokenType]int{
+	token.LPAREN:       1,
+	token.LBRACKET:     1,
+	token.LBRACE:       1,
+	token.STRING_START: 1,
+	token.RPAREN:       -1,
+	token.RBRACKET:     -1,
+	token.RBRACE:       -1,
+	token.STRING_END:   -1,
 }
 
 type Parser struct {
 	lexer                *lexer.Lexer
 	curToken             token.Token
 	peekToken            token.Token
-	errors               []string
+	errors               []ParseError
+	comments             []token.Token
+	curDoc               []token.Token // doc comments directly above curToken
+	peekDoc              []token.Token // doc comments directly above peekToken
+	grouped              map[ast.Expression]ast.Span
+	panicking            bool  // an error was reported and the statement is not yet skipped
+	braces               int   // { minus } tokens consumed so far
+	nesting              int   // brackets of any kind opened minus closed so far
+	blocks               []int // braces inside each block being parsed, innermost last
 	prefixParseFunctions map[token.TokenType]func() ast.Expression
 	infixParseFunctions  map[token.TokenType]func(ast.Expression) ast.Expression
 }
@@ -45,32 +95,53 @@ type Parser struct {
 func New(lexer *lexer.Lexer) *Parser {
 
 	p := &Parser{
-		lexer: lexer,
+		lexer:   lexer,
+		grouped: map[ast.Expression]ast.Span{},
 	}
 
 	p.prefixParseFunctions = map[token.TokenType]func() ast.Expression{
-		token.NUMBER:     p.parseNumberExpression,
-		token.IDENTIFIER: p.parseIdentifierExpression,
-		token.STRING:     p.parseStringExpression,
-		token.MINUS:      p.parsePrefixExpression,
-		token.BANG:       p.parsePrefixExpression,
-		token.TRUE:       p.parseBooleanExpression,
-		token.FALSE:      p.parseBooleanExpression,
-		token.LPAREN:     p.parseGroupedExpression,
-		token.FUNCTION:   p.parseFunctionExpression,
+		token.NUMBER:       p.parseNumberExpression,
+		token.FLOAT:        p.parseFloatExpression,
+		token.IDENTIFIER:   p.parseIdentifierExpression,
+		token.STRING:       p.parseStringExpression,
+		token.STRING_START: p.parseInterpolatedStringExpression,
+		token.MINUS:        p.parsePrefixExpression,
+		token.BANG:         p.parsePrefixExpression,
+		token.BIT_NOT:      p.parsePrefixExpression,
+		token.TRUE:         p.parseBooleanExpression,
+		token.FALSE:        p.parseBooleanExpression,
+		token.LPAREN:       p.parseGroupedExpression,
+		token.FUNCTION:     p.parseFunctionExpression,
+		token.IF:           p.parseIfExpression,
+		token.LBRACKET:     p.parseArrayExpression,
+		token.LBRACE:       p.parseHashExpression,
+		token.ILLEGAL:      p.parseIllegal,
 	}
 
 	p.infixParseFunctions = map[token.TokenType]func(ast.Expression) ast.Expression{
-		token.PLUS:     p.parseInfixExpression,
-		token.MINUS:    p.parseInfixExpression,
-		token.SLASH:    p.parseInfixExpression,
-		token.ASTERISK: p.parseInfixExpression,
-		token.MODULO:   p.parseInfixExpression,
-		token.EQ:       p.parseInfixExpression,
-		token.NOT_EQ:   p.parseInfixExpression,
-		token.LT:       p.parseInfixExpression,
-		token.GT:       p.parseInfixExpression,
-		token.LPAREN:   p.parseCallExpression,
+		token.PLUS:        p.parseInfixExpression,
+		token.MINUS:       p.parseInfixExpression,
+		token.SLASH:       p.parseInfixExpression,
+		token.ASTERISK:    p.parseInfixExpression,
+		token.POWER:       p.parseInfixExpression,
+		token.MODULO:      p.parseInfixExpression,
+		token.EQ:          p.parseInfixExpression,
+		token.NOT_EQ:      p.parseInfixExpression,
+		token.LT:          p.parseInfixExpression,
+		token.GT:          p.parseInfixExpression,
+		token.LT_EQ:       p.parseInfixExpression,
+		token.GT_EQ:       p.parseInfixExpression,
+		token.BIT_OR:      p.parseInfixExpression,
+		token.BIT_XOR:     p.parseInfixExpression,
+		token.BIT_AND:     p.parseInfixExpression,
+		token.SHIFT_LEFT:  p.parseInfixExpression,
+		token.SHIFT_RIGHT: p.parseInfixExpression,
+		token.LPAREN:      p.parseCallExpression,
+		token.LBRACKET:    p.parseIndexExpression,
+		token.AND:         p.parseLogicalExpression,
+		token.OR:          p.parseLogicalExpression,
+		token.COALESCE:    p.parseLogicalExpression,
+		token.QUESTION:    p.parseConditionalExpression,
 	}
 
 	p.advance()
@@ -85,21 +156,165 @@ func (p *Parser) Parse() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for !(p.curToken.Type == token.EOF) {
+		level := p.nesting - nesting[p.curToken.Type]
 		statement := p.parseStatement()
+		program.Statements = append(program.Statements, statement)
 
-		if statement != nil {
-			program.Statements = append(program.Statements, statement)
+		if p.panicking {
+			p.synchronize(level)
 		}
 
 		p.advance()
 	}
 
+	program.Comments = p.comments
+
 	return program
 }
 
+// advance moves to the next token, setting comments aside so that the rest
+// of the parser never sees them.
 func (p *Parser) advance() {
 	p.curToken = p.peekToken
+	p.nesting += nesting[p.curToken.Type]
+
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.braces += 1
+	case token.RBRACE:
+		p.braces -= 1
+	}
+
+	p.curDoc = p.peekDoc
+	p.peekDoc = nil
 	p.peekToken = p.lexer.NextToken()
+
+	// a doc comment documents the next token only if it starts on a line of
+	// its own and, with the doc comments just above it, runs unbroken down
+	// to the line before that token
+	previous := p.curToken.EndLine
+
+	for isComment(p.peekToken.Type) {
+		comment := p.peekToken
+		p.comments = append(p.comments, comment)
+
+		switch {
+		case comment.Type != token.DOC_COMMENT || comment.Line == previous:
+			p.peekDoc = nil
+		case len(p.peekDoc) > 0 && comment.Line != p.peekDoc[len(p.peekDoc)-1].EndLine+1:
+			p.peekDoc = []token.Token{comment}
+		default:
+			p.peekDoc = append(p.peekDoc, comment)
+		}
+
+		previous = comment.EndLine
+		p.peekToken = p.lexer.NextToken()
+	}
+
+	if len(p.peekDoc) > 0 && p.peekDoc[len(p.peekDoc)-1].EndLine != p.peekToken.Line-1 {
+		p.peekDoc = nil
+	}
+}
+
+// synchronize skips the rest of a statement that had an error, so that the
+// next statement is parsed afresh and only independent errors are reported.
+// It stops after a ';', before the '}' that closes the enclosing block and
+// before a keyword that starts a statement. As ';' is optional, it also
+// stops before a token on a new line and before a declaration or
+// assignment, but only once the brackets left open by the bad statement are
+// closed, that is once the nesting is back to level, the nesting the
+// statement started at. A declaration right at the error is the exception:
+// it shows that the statement was cut short, as in "[1, 2" followed by
+// "x := 3" on the next line.
+func (p *Parser) synchronize(level int) {
+	p.panicking = false
+
+	if p.peekIsDeclaration() {
+		return
+	}
+
+	for p.curToken.Type != token.SEMICOLON && p.peekToken.Type != token.EOF {
+		if len(p.blocks) > 0 {
+			depth := p.blocks[len(p.blocks)-1]
+
+			// the bad statement may already have consumed the closing '}'
+			if p.braces < depth || (p.braces == depth && p.peekToken.Type == token.RBRACE) {
+				return
+			}
+		}
+
+		switch p.peekToken.Type {
+		case token.RETURN, token.WHILE, token.FOR, token.BREAK, token.CONTINUE, token.IF:
+			return
+		}
+
+		if p.nesting <= level && (p.peekToken.Line > p.curToken.EndLine || p.peekIsDeclaration()) {
+			return
+		}
+
+		p.advance()
+	}
+}
+
+// peekIsDeclaration reports whether the peek token starts an identifier
+// declaration or assignment such as x := or x +=.
+func (p *Parser) peekIsDeclaration() bool {
+	if p.peekToken.Type != token.IDENTIFIER {
+		return false
+	}
+
+	next := p.peekNext().Type
+
+	return next == token.VAR || next == token.CONST || isAssignment(next)
+}
+
+// badExpression returns a placeholder for an expression that failed to
+// parse, spanning from start to the current token.
+func (p *Parser) badExpression(tok token.Token, start token.Position) ast.Expression {
+	return &ast.BadExpression{Token: tok, Extent: p.spanFrom(start)}
+}
+
+func (p *Parser) badStatement(tok token.Token) ast.Statement {
+	return &ast.BadStatement{Token: tok, Extent: p.spanFrom(tok.Pos())}
+}
+
+// spanFrom returns the span from start to the end of the current token.
+// Parse functions finish on the last token of what they parsed, so calling
+// it just before returning covers the whole construct.
+func (p *Parser) spanFrom(start token.Position) ast.Span {
+	return ast.Span{Start: start, End: p.curToken.EndPos()}
+}
+
+// startOf returns where the left operand of an infix construct begins,
+// including any parentheses around it. fallback is used when parsing the
+// operand failed.
+func (p *Parser) startOf(expr ast.Expression, fallback token.Token) token.Position {
+	if expr == nil {
+		return fallback.Pos()
+	}
+
+	if span, ok := p.grouped[expr]; ok {
+		return span.Start
+	}
+
+	return expr.Span().Start
+}
+
+func isComment(t token.TokenType) bool {
+	return t == token.COMMENT || t == token.DOC_COMMENT
+}
+
+// docText joins doc comments into plain text, dropping the /// markers and
+// the single space that usually follows them.
+func docText(comments []token.Token) string {
+	lines := make([]string, len(comments))
+
+	for i, comment := range comments {
+		line := strings.TrimPrefix(comment.Value, "///")
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 func (p *Parser) parseStatement() ast.Statement {
@@ -109,6 +324,27 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseIdentifierStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.LBRACE:
+		if p.isHashStart() {
+			return p.parseExpressionStatement()
+		}
+
+		block := p.parseBlockStatement()
+
+		if p.peekToken.Type == token.SEMICOLON {
+			p.advance()
+			block.Extent = p.spanFrom(block.Token.Pos())
+		}
+
+		return block
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -119,10 +355,16 @@ func (p *Parser) parseExpressionStatement() ast.Statement {
 	statement := &ast.ExpressionStatement{Token: p.curToken}
 	statement.Expression = p.parseExpression(LOWEST)
 
+	if target, ok := statement.Expression.(*ast.IndexExpression); ok && isAssignment(p.peekToken.Type) {
+		return p.parseIndexAssignStatement(statement.Token, target)
+	}
+
 	if p.peekToken.Type == token.SEMICOLON {
 		p.advance()
 	}
 
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
 	return statement
 }
 
@@ -137,6 +379,130 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 		p.advance()
 	}
 
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
+	return statement
+}
+
+// isAssignment reports whether t is = or one of the compound assignments.
+func isAssignment(t token.TokenType) bool {
+	switch t {
+	case token.ASSIGN, token.PLUS_ASSIGN, token.MINUS_ASSIGN, token.ASTERISK_ASSIGN, token.SLASH_ASSIGN, token.MODULO_ASSIGN:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Parser) parseIndexAssignStatement(start token.Token, target *ast.IndexExpression) ast.Statement {
+
+	statement := &ast.IndexAssignStatement{Token: start, Target: target}
+	p.advance()
+
+	statement.Type = p.curToken
+
+	p.advance()
+	statement.Value = p.parseExpression(LOWEST)
+
+	if p.peekToken.Type == token.SEMICOLON {
+		p.advance()
+	}
+
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
+	return statement
+}
+
+func (p *Parser) parseWhileStatement() ast.Statement {
+
+	statement := &ast.WhileStatement{Token: p.curToken}
+	p.advance()
+
+	statement.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badStatement(statement.Token)
+	}
+
+	statement.Body = p.parseBlockStatement()
+
+	if p.peekToken.Type == token.SEMICOLON {
+		p.advance()
+	}
+
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
+	return statement
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+
+	statement := &ast.ForStatement{Token: p.curToken}
+	p.advance()
+
+	// init; the identifier statement forms already consume their ';'
+	if p.curToken.Type != token.SEMICOLON {
+		statement.Init = p.parseStatement()
+
+		if p.curToken.Type != token.SEMICOLON && !p.expectPeek(token.SEMICOLON) {
+			return p.badStatement(statement.Token)
+		}
+	}
+
+	// condition
+	if p.peekToken.Type != token.SEMICOLON {
+		p.advance()
+		statement.Condition = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.SEMICOLON) {
+		return p.badStatement(statement.Token)
+	}
+
+	// post
+	if p.peekToken.Type != token.LBRACE {
+		p.advance()
+		statement.Post = p.parseStatement()
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badStatement(statement.Token)
+	}
+
+	statement.Body = p.parseBlockStatement()
+
+	if p.peekToken.Type == token.SEMICOLON {
+		p.advance()
+	}
+
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
+	return statement
+}
+
+func (p *Parser) parseBreakStatement() ast.Statement {
+
+	statement := &ast.BreakStatement{Token: p.curToken}
+
+	if p.peekToken.Type == token.SEMICOLON {
+		p.advance()
+	}
+
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
+	return statement
+}
+
+func (p *Parser) parseContinueStatement() ast.Statement {
+
+	statement := &ast.ContinueStatement{Token: p.curToken}
+
+	if p.peekToken.Type == token.SEMICOLON {
+		p.advance()
+	}
+
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
 	return statement
 }
 
@@ -147,16 +513,17 @@ func (p *Parser) parseIdentifierStatement() ast.Statement {
 		return p.parseConstIdentifierStatement()
 	case token.VAR:
 		return p.parseVarIdentifierStatement()
-	case token.ASSIGN:
-		return p.parseAssignIdentifierStatement()
 	default:
+		if isAssignment(p.peekToken.Type) {
+			return p.parseAssignIdentifierStatement()
+		}
 		return p.parseExpressionStatement()
 	}
 }
 
 func (p *Parser) parseConstIdentifierStatement() ast.Statement {
 
-	statement := &ast.IdentifierStatement{Token: p.curToken}
+	statement := &ast.IdentifierStatement{Token: p.curToken, Doc: docText(p.curDoc)}
 	p.advance()
 
 	statement.Type = p.curToken
@@ -168,6 +535,8 @@ func (p *Parser) parseConstIdentifierStatement() ast.Statement {
 		p.advance()
 	}
 
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
 	return statement
 }
 
@@ -185,6 +554,8 @@ func (p *Parser) parseVarIdentifierStatement() ast.Statement {
 		p.advance()
 	}
 
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
 	return statement
 }
 
@@ -202,6 +573,8 @@ func (p *Parser) parseAssignIdentifierStatement() ast.Statement {
 		p.advance()
 	}
 
+	statement.Extent = p.spanFrom(statement.Token.Pos())
+
 	return statement
 }
 
@@ -209,9 +582,8 @@ func (p *Parser) parseExpression(precendence int) ast.Expression {
 	prefix := p.prefixParseFunctions[p.curToken.Type]
 
 	if prefix == nil {
-		msg := fmt.Sprintf("Error: line: %d. Message: no prefix parse function for %s found\n", p.curToken.Line, p.curToken.Type)
-		p.errors = append(p.errors, msg)
-		return nil
+		p.errorAt(p.curToken, NoPrefix, "no prefix parse function for %s found", p.curToken.Type)
+		return p.badExpression(p.curToken, p.curToken.Pos())
 	}
 
 	leftExpression := prefix()
@@ -234,41 +606,143 @@ func (p *Parser) parseExpression(precendence int) ast.Expression {
 func (p *Parser) parseInfixExpression(expr ast.Expression) ast.Expression {
 
 	expression := &ast.InfixExpression{
-		Token:    p.curToken,
 		Operator: p.curToken,
 		Left:     expr,
 	}
 
 	precendence := p.currentPrecedence()
 
+	if rightAssociative[p.curToken.Type] {
+		precendence--
+	}
+
 	p.advance()
 	expression.Right = p.parseExpression(precendence)
+	expression.Extent = p.spanFrom(p.startOf(expr, expression.Operator))
+
+	return expression
+}
+
+func (p *Parser) parseLogicalExpression(expr ast.Expression) ast.Expression {
+
+	expression := &ast.LogicalExpression{
+		Operator: p.curToken,
+		Left:     expr,
+	}
+
+	precendence := p.currentPrecedence()
+
+	p.advance()
+	expression.Right = p.parseExpression(precendence)
+	expression.Extent = p.spanFrom(p.startOf(expr, expression.Operator))
+
+	return expression
+}
+
+// parseConditionalExpression parses both branches at LOWEST, which makes
+// the ternary right-associative: a ? b : c ? d : e nests in the alternative.
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	expression := &ast.ConditionalExpression{
+		Token:     p.curToken,
+		Condition: condition,
+	}
+
+	p.advance()
+	expression.Consequence = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COLON) {
+		return p.badExpression(expression.Token, p.startOf(condition, expression.Token))
+	}
+
+	p.advance()
+	expression.Alternative = p.parseExpression(LOWEST)
+	expression.Extent = p.spanFrom(p.startOf(condition, expression.Token))
 
 	return expression
 }
 
 func (p *Parser) parseNumberExpression() ast.Expression {
 	number := &ast.NumberExpression{
-		Token: p.curToken,
+		Token:  p.curToken,
+		Extent: p.spanFrom(p.curToken.Pos()),
 	}
 
-	value, err := strconv.ParseInt(p.curToken.Value, 0, 64)
+	digits, base := integerLiteral(p.curToken.Value)
 
-	if err != nil {
-		msg := fmt.Sprintf("Error: line: %d. Message: could not parse %q as integer\n", p.curToken.Line, p.curToken.Value)
-		p.errors = append(p.errors, msg)
-		return nil
+	value, err := strconv.ParseInt(digits, base, strconv.IntSize)
+
+	if err == nil {
+		number.Value = int(value)
+		return number
 	}
 
-	number.Value = int(value)
+	// literals that do not fit in an int are kept as big integers
+	bigValue, ok := new(big.Int).SetString(digits, base)
+
+	if !ok {
+		p.errorAt(p.curToken, InvalidInteger, "could not parse %q as integer", p.curToken.Value)
+		return p.badExpression(p.curToken, p.curToken.Pos())
+	}
+
+	number.Big = bigValue
 
 	return number
 }
 
+// integerLiteral strips the base prefix and _ separators from a NUMBER
+// token. Unprefixed literals are always decimal, so 010 is ten.
+func integerLiteral(literal string) (string, int) {
+	base := 10
+
+	if len(literal) > 2 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+	}
+
+	if base != 10 {
+		literal = literal[2:]
+	}
+
+	return strings.ReplaceAll(literal, "_", ""), base
+}
+
+func (p *Parser) parseFloatExpression() ast.Expression {
+	float := &ast.FloatExpression{
+		Token:  p.curToken,
+		Extent: p.spanFrom(p.curToken.Pos()),
+	}
+
+	value, err := strconv.ParseFloat(p.curToken.Value, 64)
+
+	if err != nil {
+		p.errorAt(p.curToken, InvalidFloat, "could not parse %q as float", p.curToken.Value)
+		return p.badExpression(p.curToken, p.curToken.Pos())
+	}
+
+	float.Value = value
+
+	return float
+}
+
+// parseIllegal reports the lexer's message for an ILLEGAL token, such as an
+// unterminated string or an invalid escape sequence.
+func (p *Parser) parseIllegal() ast.Expression {
+	p.errorAt(p.curToken, IllegalToken, "illegal token: %s", p.curToken.Value)
+
+	return p.badExpression(p.curToken, p.curToken.Pos())
+}
+
 func (p *Parser) parseIdentifierExpression() ast.Expression {
 	identifier := &ast.IdentifierExpression{
-		Token: p.curToken,
-		Value: p.curToken.Value,
+		Token:  p.curToken,
+		Value:  p.curToken.Value,
+		Extent: p.spanFrom(p.curToken.Pos()),
 	}
 
 	return identifier
@@ -276,16 +750,46 @@ func (p *Parser) parseIdentifierExpression() ast.Expression {
 
 func (p *Parser) parseStringExpression() ast.Expression {
 	str := &ast.StringExpression{
-		Token: p.curToken,
-		Value: p.curToken.Value,
+		Token:  p.curToken,
+		Value:  p.curToken.Value,
+		Extent: p.spanFrom(p.curToken.Pos()),
 	}
 
 	return str
 }
 
+func (p *Parser) parseInterpolatedStringExpression() ast.Expression {
+	str := &ast.InterpolatedStringExpression{
+		Token: p.curToken,
+		Parts: []ast.Expression{},
+	}
+
+	for {
+		if p.curToken.Value != "" {
+			str.Parts = append(str.Parts, &ast.StringExpression{Token: p.curToken, Value: p.curToken.Value, Extent: p.spanFrom(p.curToken.Pos())})
+		}
+
+		if p.curToken.Type == token.STRING_END {
+			str.Extent = p.spanFrom(str.Token.Pos())
+			return str
+		}
+
+		p.advance()
+
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		if p.peekToken.Type == token.STRING_MIDDLE {
+			p.advance()
+		} else if !p.expectPeek(token.STRING_END) {
+			return p.badExpression(str.Token, str.Token.Pos())
+		}
+	}
+}
+
 func (p *Parser) parseBooleanExpression() ast.Expression {
 	boolean := &ast.BooleanExpression{
-		Token: p.curToken,
+		Token:  p.curToken,
+		Extent: p.spanFrom(p.curToken.Pos()),
 	}
 
   if (p.curToken.Value == "true") {
@@ -305,20 +809,27 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 
 	p.advance()
 
-	expression.Right = p.parseExpression(PREFIX)
+	// ** binds tighter than a prefix operator, so -2 ** 2 is -(2 ** 2)
+	expression.Right = p.parseExpression(POWER - 1)
+	expression.Extent = p.spanFrom(expression.Token.Pos())
 
 	return expression
 }
 
+// parseGroupedExpression returns the inner expression itself and records
+// the span including the parentheses in p.grouped.
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	start := p.curToken
 	p.advance()
 
 	expression := p.parseExpression(LOWEST)
 
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return p.badExpression(start, start.Pos())
 	}
 
+	p.grouped[expression] = p.spanFrom(start.Pos())
+
 	return expression
 }
 
@@ -326,32 +837,81 @@ func (p *Parser) parseFunctionExpression() ast.Expression {
 	function := &ast.FunctionExpression{Token: p.curToken}
 
 	if !p.expectPeek(token.LPAREN) {
-		return nil
+		return p.badExpression(function.Token, function.Token.Pos())
 	}
 
 	function.Parameters = p.parseFunctionParameters()
 
-	if !p.expectPeek(token.LBRACE) {
-		return nil
+	if function.Parameters == nil || !p.expectPeek(token.LBRACE) {
+		return p.badExpression(function.Token, function.Token.Pos())
 	}
 
 	function.Body = p.parseBlockStatement()
+	function.Extent = p.spanFrom(function.Token.Pos())
 
 	return function
 }
 
+func (p *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{Token: p.curToken}
+
+	p.advance()
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badExpression(expression.Token, expression.Token.Pos())
+	}
+
+	expression.Consequence = p.parseBlockStatement()
+
+	if p.peekToken.Type != token.ELSE {
+		expression.Extent = p.spanFrom(expression.Token.Pos())
+		return expression
+	}
+
+	p.advance()
+
+	// else if: wrap the nested if in a block so Alternative stays a block
+	if p.peekToken.Type == token.IF {
+		p.advance()
+
+		alternative := &ast.BlockStatement{Token: p.curToken}
+		nested := &ast.ExpressionStatement{Token: p.curToken}
+		nested.Expression = p.parseIfExpression()
+
+		nested.Extent = nested.Expression.Span()
+		alternative.Statements = []ast.Statement{nested}
+		alternative.Extent = nested.Extent
+		expression.Alternative = alternative
+		expression.Extent = p.spanFrom(expression.Token.Pos())
+
+		return expression
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return p.badExpression(expression.Token, expression.Token.Pos())
+	}
+
+	expression.Alternative = p.parseBlockStatement()
+	expression.Extent = p.spanFrom(expression.Token.Pos())
+
+	return expression
+}
+
 func (p *Parser) parseFunctionParameters() []*ast.IdentifierExpression {
 	identifiers := []*ast.IdentifierExpression{}
 
 	if p.peekToken.Type == token.RPAREN {
+		p.advance()
 		return identifiers
 	}
 
 	p.advance()
 
 	ident := &ast.IdentifierExpression{
-		Token: p.curToken,
-		Value: p.curToken.Value,
+		Token:  p.curToken,
+		Value:  p.curToken.Value,
+		Extent: p.spanFrom(p.curToken.Pos()),
 	}
 
 	identifiers = append(identifiers, ident)
@@ -360,8 +920,9 @@ func (p *Parser) parseFunctionParameters() []*ast.IdentifierExpression {
 		p.advance()
 		p.advance()
 		ident := &ast.IdentifierExpression{
-			Token: p.curToken,
-			Value: p.curToken.Value,
+			Token:  p.curToken,
+			Value:  p.curToken.Value,
+			Extent: p.spanFrom(p.curToken.Pos()),
 		}
 		identifiers = append(identifiers, ident)
 	}
@@ -380,15 +941,36 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 
 	blockstatement.Statements = []ast.Statement{}
 
+	depth := p.braces
+	p.blocks = append(p.blocks, depth)
+	defer func() { p.blocks = p.blocks[:len(p.blocks)-1] }()
+
 	p.advance()
 
 	for !(p.curToken.Type == token.RBRACE) && !(p.curToken.Type == token.EOF) {
+		level := p.nesting - nesting[p.curToken.Type]
 		statement := p.parseStatement()
-		if statement != nil {
-			blockstatement.Statements = append(blockstatement.Statements, statement)
+		blockstatement.Statements = append(blockstatement.Statements, statement)
+
+		if p.panicking {
+			p.synchronize(level)
+		}
+
+		// a bad statement may have consumed the closing '}' already
+		if p.braces < depth {
+			break
 		}
+
 		p.advance()
 	}
+
+	if p.curToken.Type == token.EOF && p.braces >= depth {
+		err := p.errorAt(p.curToken, UnexpectedToken, "expected next token to be: %s, got %s instead", token.RBRACE, token.EOF)
+		err.Expected = token.RBRACE
+	}
+
+	blockstatement.Extent = p.spanFrom(blockstatement.Token.Pos())
+
 	return blockstatement
 }
 
@@ -400,9 +982,104 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 
 	expression.Arguments = p.parseExpressionList(token.RPAREN, token.COMMA)
 
+	if expression.Arguments == nil {
+		return p.badExpression(expression.Token, p.startOf(function, expression.Token))
+	}
+	expression.Extent = p.spanFrom(p.startOf(function, expression.Token))
+
 	return expression
 }
 
+func (p *Parser) parseArrayExpression() ast.Expression {
+	array := &ast.ArrayExpression{Token: p.curToken}
+
+	array.Elements = p.parseExpressionList(token.RBRACKET, token.COMMA)
+
+	if array.Elements == nil {
+		return p.badExpression(array.Token, array.Token.Pos())
+	}
+
+	array.Extent = p.spanFrom(array.Token.Pos())
+
+	return array
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{
+		Token: p.curToken,
+		Left:  left,
+	}
+
+	p.advance()
+	expression.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return p.badExpression(expression.Token, p.startOf(left, expression.Token))
+	}
+
+	expression.Extent = p.spanFrom(p.startOf(left, expression.Token))
+
+	return expression
+}
+
+func (p *Parser) parseHashExpression() ast.Expression {
+	hash := &ast.HashExpression{
+		Token:  p.curToken,
+		Keys:   []ast.Expression{},
+		Values: []ast.Expression{},
+	}
+
+	for p.peekToken.Type != token.RBRACE {
+		p.advance()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return p.badExpression(hash.Token, hash.Token.Pos())
+		}
+
+		p.advance()
+		value := p.parseExpression(LOWEST)
+
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if p.peekToken.Type != token.RBRACE && !p.expectPeek(token.COMMA) {
+			return p.badExpression(hash.Token, hash.Token.Pos())
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return p.badExpression(hash.Token, hash.Token.Pos())
+	}
+
+	hash.Extent = p.spanFrom(hash.Token.Pos())
+
+	return hash
+}
+
+// isHashStart reports whether the '{' at the start of a statement opens a
+// hash literal rather than a block, i.e. whether it is followed by a single
+// token key and a ':'. Anything else, including '{}', is a block.
+func (p *Parser) isHashStart() bool {
+	if p.peekToken.Type == token.RBRACE {
+		return false
+	}
+
+	return p.peekNext().Type == token.COLON
+}
+
+// peekNext returns the token after the peek token without consuming it.
+func (p *Parser) peekNext() token.Token {
+	saved := p.lexer.Save()
+	next := p.lexer.NextToken()
+	for isComment(next.Type) {
+		next = p.lexer.NextToken()
+	}
+	p.lexer.Restore(saved)
+
+	return next
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType, delim token.TokenType) []ast.Expression {
 	expressionlist := []ast.Expression{}
 
@@ -455,22 +1132,6 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("Error: line: %d. Message: expected next token to be: %s, got %s instead", p.curToken.Line, t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
-}
-
-func (p *Parser) ReportParserErrors() (string, error) {
-
-	errmsg := ""
-
-	if len(p.errors) == 0 {
-		return "", nil
-	}
-
-	for _, msg := range p.errors {
-		errmsg += fmt.Sprintf("parser error: %q", msg)
-		errmsg += fmt.Sprintf("Error: line: %d. Message: could not parse %q as integer\n", p.curToken.Line, p.curToken.Value)
-	}
-
-	return errmsg, errors.New("Parser reported errors")
+	err := p.errorAt(p.peekToken, UnexpectedToken, "expected next token to be: %s, got %s instead", t, p.peekToken.Type)
+	err.Expected = t
 }
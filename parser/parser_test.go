@@ -3,7 +3,9 @@ package parser
 import (
 	"strings"
 	"testing"
+	"ziplang/ast"
 	"ziplang/lexer"
+	"ziplang/token"
 )
 
 func TestParserCallExpression(t *testing.T) {
@@ -61,9 +63,8 @@ func TestParserCallExpression(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -86,11 +87,6 @@ func TestParserInfixExpresion(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: NOT_EQ,
-            Value: !=,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -124,11 +120,6 @@ func TestParserInfixExpresion(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: EQ,
-            Value: ==,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -162,11 +153,6 @@ func TestParserInfixExpresion(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: GT,
-            Value: >,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -200,11 +186,6 @@ func TestParserInfixExpresion(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: LT,
-            Value: <,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -238,11 +219,6 @@ func TestParserInfixExpresion(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: SLASH,
-            Value: /,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -276,9 +252,8 @@ func TestParserInfixExpresion(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -338,6 +313,45 @@ func TestParserFunctionExpression(t *testing.T) {
           },
         },
       },
+    }`},
+		{"fn() { 1 }",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: FUNCTION,
+          Value: fn,
+          Line: 1,
+        },
+        Expression: FunctionExpression {
+          Token: Token{
+            Type: FUNCTION,
+            Value: fn,
+            Line: 1,
+          },
+          Parameters: Body: BlockStatement {
+            Token: Token {
+              Type: LBRACE,
+              Value: {,
+              Line: 1,
+            },
+            Statements: ExpressionStatement {
+              Token: Token {
+                Type: NUMBER,
+                Value: 1,
+                Line: 1,
+              },
+              Expression: NumberExpression {
+                Token: Token {
+                  Type: NUMBER,
+                  Value: 1,
+                  Line: 1,
+                },
+                Value: 1,
+              },
+            },
+          },
+        },
+      },
     }`},
 	}
 
@@ -348,9 +362,8 @@ func TestParserFunctionExpression(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -374,17 +387,7 @@ func TestParserGroupedExpression(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: ASTERISK,
-            Value: *,
-            Line: 1,
-          },
           Left: InfixExpression {
-            Token: Token {
-              Type: PLUS,
-              Value: +,
-              Line: 1,
-            },
             Left: NumberExpression {
               Token: Token {
                 Type: NUMBER,
@@ -432,17 +435,7 @@ func TestParserGroupedExpression(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: ASTERISK,
-            Value: *,
-            Line: 1,
-          },
           Left: InfixExpression {
-            Token: Token {
-              Type: MINUS,
-              Value: -,
-              Line: 1,
-            },
             Left: NumberExpression {
               Token: Token {
                 Type: NUMBER,
@@ -490,9 +483,8 @@ func TestParserGroupedExpression(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -575,9 +567,8 @@ func TestParserPrefixExpression(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -669,9 +660,57 @@ func TestParserIdentifierStatement(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserBlockStatement(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"{ return 1; }",
+			`Program {
+      BlockStatement {
+        Token: Token {
+          Type: LBRACE,
+          Value: {,
+          Line: 1,
+        },
+        Statements: ReturnStatement {
+          Token: Token {
+            Type: RETURN,
+            Value: return,
+            Line: 1,
+          },
+          Value: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 1,
+              Line: 1,
+            },
+            Value: 1,
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -732,10 +771,10 @@ func TestParserReturnStatement(t *testing.T) {
         Value: StringExpression {
           Token: Token {
             Type: STRING,
-            Value: "foo",
+            Value: foo,
             Line: 1,
           },
-          Value: "foo",
+          Value: foo,
         },
       },
     }`},
@@ -748,9 +787,8 @@ func TestParserReturnStatement(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -827,9 +865,8 @@ func TestParserBooleanExpression(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -848,16 +885,16 @@ func TestParserStringExpression(t *testing.T) {
       ExpressionStatement {
         Token: Token {
           Type: STRING,
-          Value: "foo",
+          Value: foo,
           Line: 1,
         },
         Expression: StringExpression {
           Token: Token {
             Type: STRING,
-            Value: "foo",
+            Value: foo,
             Line: 1,
           },
-          Value: "foo",
+          Value: foo,
         },
       },
     }`},
@@ -866,16 +903,16 @@ func TestParserStringExpression(t *testing.T) {
       ExpressionStatement {
         Token: Token {
           Type: STRING,
-          Value: "bar",
+          Value: bar,
           Line: 1,
         },
         Expression: StringExpression {
           Token: Token {
             Type: STRING,
-            Value: "bar",
+            Value: bar,
             Line: 1,
           },
-          Value: "bar",
+          Value: bar,
         },
       },
     }`},
@@ -884,16 +921,16 @@ func TestParserStringExpression(t *testing.T) {
       ExpressionStatement {
         Token: Token {
           Type: STRING,
-          Value: "baz",
+          Value: baz,
           Line: 1,
         },
         Expression: StringExpression {
           Token: Token {
             Type: STRING,
-            Value: "baz",
+            Value: baz,
             Line: 1,
           },
-          Value: "baz",
+          Value: baz,
         },
       },
     }`},
@@ -906,9 +943,8 @@ func TestParserStringExpression(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -985,9 +1021,8 @@ func TestParserIdentifierExpression(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -1010,11 +1045,6 @@ func TestParserMultiExpression(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: MINUS,
-            Value: -,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -1045,11 +1075,6 @@ func TestParserMultiExpression(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: ASTERISK,
-            Value: *,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -1083,9 +1108,8 @@ func TestParserMultiExpression(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -1094,50 +1118,175 @@ func TestParserMultiExpression(t *testing.T) {
 	}
 }
 
-func TestParserLineNumbers(t *testing.T) {
+func TestParserIfExpression(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedProgram string
 	}{
-		{`
-    1;
-    `,
+		{"if a { 1 }",
 			`Program {
       ExpressionStatement {
-        Token: Token{
-          Type: NUMBER,
-          Value: 1,
-          Line: 2,
+        Token: Token {
+          Type: IF,
+          Value: if,
+          Line: 1,
         },
-        Expression: NumberExpression {
+        Expression: IfExpression {
           Token: Token {
-            Type: NUMBER,
-            Value: 1,
-            Line: 2,
+            Type: IF,
+            Value: if,
+            Line: 1,
+          },
+          Condition: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Consequence: BlockStatement {
+            Token: Token {
+              Type: LBRACE,
+              Value: {,
+              Line: 1,
+            },
+            Statements: ExpressionStatement {
+              Token: Token {
+                Type: NUMBER,
+                Value: 1,
+                Line: 1,
+              },
+              Expression: NumberExpression {
+                Token: Token {
+                  Type: NUMBER,
+                  Value: 1,
+                  Line: 1,
+                },
+                Value: 1,
+              },
+            },
           },
-          Value: 1,
         },
       },
     }`},
-		{`
-
-
-    1337;
-    `,
+		{"if a { 1 } else if b { 2 } else { 3 }",
 			`Program {
       ExpressionStatement {
-        Token: Token{
-          Type: NUMBER,
-          Value: 1337,
-          Line: 4,
+        Token: Token {
+          Type: IF,
+          Value: if,
+          Line: 1,
         },
-        Expression: NumberExpression {
+        Expression: IfExpression {
           Token: Token {
-            Type: NUMBER,
-            Value: 1337,
-            Line: 4,
+            Type: IF,
+            Value: if,
+            Line: 1,
+          },
+          Condition: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Consequence: BlockStatement {
+            Token: Token {
+              Type: LBRACE,
+              Value: {,
+              Line: 1,
+            },
+            Statements: ExpressionStatement {
+              Token: Token {
+                Type: NUMBER,
+                Value: 1,
+                Line: 1,
+              },
+              Expression: NumberExpression {
+                Token: Token {
+                  Type: NUMBER,
+                  Value: 1,
+                  Line: 1,
+                },
+                Value: 1,
+              },
+            },
+          },
+          Alternative: BlockStatement {
+            Token: Token {
+              Type: IF,
+              Value: if,
+              Line: 1,
+            },
+            Statements: ExpressionStatement {
+              Token: Token {
+                Type: IF,
+                Value: if,
+                Line: 1,
+              },
+              Expression: IfExpression {
+                Token: Token {
+                  Type: IF,
+                  Value: if,
+                  Line: 1,
+                },
+                Condition: IdentifierExpression {
+                  Token: Token {
+                    Type: IDENTIFIER,
+                    Value: b,
+                    Line: 1,
+                  },
+                  Value: b,
+                },
+                Consequence: BlockStatement {
+                  Token: Token {
+                    Type: LBRACE,
+                    Value: {,
+                    Line: 1,
+                  },
+                  Statements: ExpressionStatement {
+                    Token: Token {
+                      Type: NUMBER,
+                      Value: 2,
+                      Line: 1,
+                    },
+                    Expression: NumberExpression {
+                      Token: Token {
+                        Type: NUMBER,
+                        Value: 2,
+                        Line: 1,
+                      },
+                      Value: 2,
+                    },
+                  },
+                },
+                Alternative: BlockStatement {
+                  Token: Token {
+                    Type: LBRACE,
+                    Value: {,
+                    Line: 1,
+                  },
+                  Statements: ExpressionStatement {
+                    Token: Token {
+                      Type: NUMBER,
+                      Value: 3,
+                      Line: 1,
+                    },
+                    Expression: NumberExpression {
+                      Token: Token {
+                        Type: NUMBER,
+                        Value: 3,
+                        Line: 1,
+                      },
+                      Value: 3,
+                    },
+                  },
+                },
+              },
+            },
           },
-          Value: 1337,
         },
       },
     }`},
@@ -1150,9 +1299,8 @@ func TestParserLineNumbers(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -1161,32 +1309,1543 @@ func TestParserLineNumbers(t *testing.T) {
 	}
 }
 
-func TestParserGeneric(t *testing.T) {
+func TestParserLoopStatements(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedProgram string
 	}{
-		{"1;",
+		{"while a { break; }",
 			`Program {
-      ExpressionStatement {
-        Token: Token{
-          Type: NUMBER,
-          Value: 1,
+      WhileStatement {
+        Token: Token {
+          Type: WHILE,
+          Value: while,
           Line: 1,
         },
-        Expression: NumberExpression {
+        Condition: IdentifierExpression {
           Token: Token {
-            Type: NUMBER,
-            Value: 1,
+            Type: IDENTIFIER,
+            Value: a,
             Line: 1,
           },
-          Value: 1,
+          Value: a,
+        },
+        Body: BlockStatement {
+          Token: Token {
+            Type: LBRACE,
+            Value: {,
+            Line: 1,
+          },
+          Statements: BreakStatement {
+            Token: Token {
+              Type: BREAK,
+              Value: break,
+              Line: 1,
+            },
+          },
         },
       },
     }`},
-		{"(1);",
+		{"for i := 0; i < 1; i = i + 1 { continue; }",
 			`Program {
-      ExpressionStatement {
+      ForStatement {
+        Token: Token {
+          Type: FOR,
+          Value: for,
+          Line: 1,
+        },
+        Init: IdentifierStatement {
+          Token: Token {
+            Type: IDENTIFIER,
+            Value: i,
+            Line: 1,
+          },
+          Type: Token {
+            Type: VAR,
+            Value: :=,
+            Line: 1,
+          },
+          Value: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 0,
+              Line: 1,
+            },
+            Value: 0,
+          },
+        },
+        Condition: InfixExpression {
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: i,
+              Line: 1,
+            },
+            Value: i,
+          },
+          Operator: Token {
+            Type: LT,
+            Value: <,
+            Line: 1,
+          },
+          Right: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 1,
+              Line: 1,
+            },
+            Value: 1,
+          },
+        },
+        Post: IdentifierStatement {
+          Token: Token {
+            Type: IDENTIFIER,
+            Value: i,
+            Line: 1,
+          },
+          Type: Token {
+            Type: ASSIGN,
+            Value: =,
+            Line: 1,
+          },
+          Value: InfixExpression {
+            Left: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: i,
+                Line: 1,
+              },
+              Value: i,
+            },
+            Operator: Token {
+              Type: PLUS,
+              Value: +,
+              Line: 1,
+            },
+            Right: NumberExpression {
+              Token: Token {
+                Type: NUMBER,
+                Value: 1,
+                Line: 1,
+              },
+              Value: 1,
+            },
+          },
+        },
+        Body: BlockStatement {
+          Token: Token {
+            Type: LBRACE,
+            Value: {,
+            Line: 1,
+          },
+          Statements: ContinueStatement {
+            Token: Token {
+              Type: CONTINUE,
+              Value: continue,
+              Line: 1,
+            },
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserArrayExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"[1, a];",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: LBRACKET,
+          Value: [,
+          Line: 1,
+        },
+        Expression: ArrayExpression {
+          Token: Token {
+            Type: LBRACKET,
+            Value: [,
+            Line: 1,
+          },
+          Elements: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 1,
+              Line: 1,
+            },
+            Value: 1,
+          },
+          IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+        },
+      },
+    }`},
+		{"a[0];",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Expression: IndexExpression {
+          Token: Token {
+            Type: LBRACKET,
+            Value: [,
+            Line: 1,
+          },
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Index: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 0,
+              Line: 1,
+            },
+            Value: 0,
+          },
+        },
+      },
+    }`},
+		{"a[1] = 2;",
+			`Program {
+      IndexAssignStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Target: IndexExpression {
+          Token: Token {
+            Type: LBRACKET,
+            Value: [,
+            Line: 1,
+          },
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Index: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 1,
+              Line: 1,
+            },
+            Value: 1,
+          },
+        },
+        Type: Token {
+          Type: ASSIGN,
+          Value: =,
+          Line: 1,
+        },
+        Value: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 2,
+            Line: 1,
+          },
+          Value: 2,
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserHashExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"{\"a\": 1, 2: b}",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: LBRACE,
+          Value: {,
+          Line: 1,
+        },
+        Expression: HashExpression {
+          Token: Token {
+            Type: LBRACE,
+            Value: {,
+            Line: 1,
+          },
+          Pairs: Key: StringExpression {
+            Token: Token {
+              Type: STRING,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Value: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 1,
+              Line: 1,
+            },
+            Value: 1,
+          },
+          Key: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 2,
+              Line: 1,
+            },
+            Value: 2,
+          },
+          Value: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: b,
+              Line: 1,
+            },
+            Value: b,
+          },
+        },
+      },
+    }`},
+		{"x := {};",
+			`Program {
+      IdentifierStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: x,
+          Line: 1,
+        },
+        Type: Token {
+          Type: VAR,
+          Value: :=,
+          Line: 1,
+        },
+        Value: HashExpression {
+          Token: Token {
+            Type: LBRACE,
+            Value: {,
+            Line: 1,
+          },
+          Pairs: },
+        },
+      }`},
+		{"{ a }",
+			`Program {
+      BlockStatement {
+        Token: Token {
+          Type: LBRACE,
+          Value: {,
+          Line: 1,
+        },
+        Statements: ExpressionStatement {
+          Token: Token {
+            Type: IDENTIFIER,
+            Value: a,
+            Line: 1,
+          },
+          Expression: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserFloatExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"2.5e3;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: FLOAT,
+          Value: 2.5e3,
+          Line: 1,
+        },
+        Expression: FloatExpression {
+          Token: Token {
+            Type: FLOAT,
+            Value: 2.5e3,
+            Line: 1,
+          },
+          Value: 2500,
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserBigNumberExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"123456789012345678901234567890;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: NUMBER,
+          Value: 123456789012345678901234567890,
+          Line: 1,
+        },
+        Expression: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 123456789012345678901234567890,
+            Line: 1,
+          },
+          Value: 123456789012345678901234567890,
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserInterpolatedStringExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"\"a ${b} c${1}\";",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: STRING_START,
+          Value: a ,
+          Line: 1,
+        },
+        Expression: InterpolatedStringExpression {
+          Token: Token {
+            Type: STRING_START,
+            Value: a ,
+            Line: 1,
+          },
+          Parts: StringExpression {
+            Token: Token {
+              Type: STRING_START,
+              Value: a ,
+              Line: 1,
+            },
+            Value: a ,
+          },
+          IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: b,
+              Line: 1,
+            },
+            Value: b,
+          },
+          StringExpression {
+            Token: Token {
+              Type: STRING_MIDDLE,
+              Value:  c,
+              Line: 1,
+            },
+            Value:  c,
+          },
+          NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 1,
+              Line: 1,
+            },
+            Value: 1,
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserLogicalExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"a || b && c == d;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Expression: LogicalExpression {
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Operator: Token {
+            Type: OR,
+            Value: ||,
+            Line: 1,
+          },
+          Right: LogicalExpression {
+            Left: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: b,
+                Line: 1,
+              },
+              Value: b,
+            },
+            Operator: Token {
+              Type: AND,
+              Value: &&,
+              Line: 1,
+            },
+            Right: InfixExpression {
+              Left: IdentifierExpression {
+                Token: Token {
+                  Type: IDENTIFIER,
+                  Value: c,
+                  Line: 1,
+                },
+                Value: c,
+              },
+              Operator: Token {
+                Type: EQ,
+                Value: ==,
+                Line: 1,
+              },
+              Right: IdentifierExpression {
+                Token: Token {
+                  Type: IDENTIFIER,
+                  Value: d,
+                  Line: 1,
+                },
+                Value: d,
+              },
+            },
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserCompoundAssignment(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"x += 1;",
+			`Program {
+      IdentifierStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: x,
+          Line: 1,
+        },
+        Type: Token {
+          Type: PLUS_ASSIGN,
+          Value: +=,
+          Line: 1,
+        },
+        Value: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 1,
+            Line: 1,
+          },
+          Value: 1,
+        },
+      },
+    }`},
+		{"a[0] %= 2;",
+			`Program {
+      IndexAssignStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Target: IndexExpression {
+          Token: Token {
+            Type: LBRACKET,
+            Value: [,
+            Line: 1,
+          },
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Index: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 0,
+              Line: 1,
+            },
+            Value: 0,
+          },
+        },
+        Type: Token {
+          Type: MODULO_ASSIGN,
+          Value: %=,
+          Line: 1,
+        },
+        Value: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 2,
+            Line: 1,
+          },
+          Value: 2,
+        },
+      },
+    }`},
+		{"a <= b;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Expression: InfixExpression {
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Operator: Token {
+            Type: LT_EQ,
+            Value: <=,
+            Line: 1,
+          },
+          Right: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: b,
+              Line: 1,
+            },
+            Value: b,
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserBitwiseExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"a | b ^ c & d << 1 >> e;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Expression: InfixExpression {
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Operator: Token {
+            Type: BIT_OR,
+            Value: |,
+            Line: 1,
+          },
+          Right: InfixExpression {
+            Left: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: b,
+                Line: 1,
+              },
+              Value: b,
+            },
+            Operator: Token {
+              Type: BIT_XOR,
+              Value: ^,
+              Line: 1,
+            },
+            Right: InfixExpression {
+              Left: IdentifierExpression {
+                Token: Token {
+                  Type: IDENTIFIER,
+                  Value: c,
+                  Line: 1,
+                },
+                Value: c,
+              },
+              Operator: Token {
+                Type: BIT_AND,
+                Value: &,
+                Line: 1,
+              },
+              Right: InfixExpression {
+                Left: InfixExpression {
+                  Left: IdentifierExpression {
+                    Token: Token {
+                      Type: IDENTIFIER,
+                      Value: d,
+                      Line: 1,
+                    },
+                    Value: d,
+                  },
+                  Operator: Token {
+                    Type: SHIFT_LEFT,
+                    Value: <<,
+                    Line: 1,
+                  },
+                  Right: NumberExpression {
+                    Token: Token {
+                      Type: NUMBER,
+                      Value: 1,
+                      Line: 1,
+                    },
+                    Value: 1,
+                  },
+                },
+                Operator: Token {
+                  Type: SHIFT_RIGHT,
+                  Value: >>,
+                  Line: 1,
+                },
+                Right: IdentifierExpression {
+                  Token: Token {
+                    Type: IDENTIFIER,
+                    Value: e,
+                    Line: 1,
+                  },
+                  Value: e,
+                },
+              },
+            },
+          },
+        },
+      },
+    }`},
+		{"~x + 1;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: BIT_NOT,
+          Value: ~,
+          Line: 1,
+        },
+        Expression: InfixExpression {
+          Left: PrefixExpression {
+            Token: Token {
+              Type: BIT_NOT,
+              Value: ~,
+              Line: 1,
+            },
+            Operator: Token {
+              Type: BIT_NOT,
+              Value: ~,
+              Line: 1,
+            },
+            Right: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: x,
+                Line: 1,
+              },
+              Value: x,
+            },
+          },
+          Operator: Token {
+            Type: PLUS,
+            Value: +,
+            Line: 1,
+          },
+          Right: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 1,
+              Line: 1,
+            },
+            Value: 1,
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserPowerExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"a ** b ** c;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Expression: InfixExpression {
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Operator: Token {
+            Type: POWER,
+            Value: **,
+            Line: 1,
+          },
+          Right: InfixExpression {
+            Left: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: b,
+                Line: 1,
+              },
+              Value: b,
+            },
+            Operator: Token {
+              Type: POWER,
+              Value: **,
+              Line: 1,
+            },
+            Right: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: c,
+                Line: 1,
+              },
+              Value: c,
+            },
+          },
+        },
+      },
+    }`},
+		{"a * b ** c;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Expression: InfixExpression {
+          Left: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Operator: Token {
+            Type: ASTERISK,
+            Value: *,
+            Line: 1,
+          },
+          Right: InfixExpression {
+            Left: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: b,
+                Line: 1,
+              },
+              Value: b,
+            },
+            Operator: Token {
+              Type: POWER,
+              Value: **,
+              Line: 1,
+            },
+            Right: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: c,
+                Line: 1,
+              },
+              Value: c,
+            },
+          },
+        },
+      },
+    }`},
+		{"-a ** b;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: MINUS,
+          Value: -,
+          Line: 1,
+        },
+        Expression: PrefixExpression {
+          Token: Token {
+            Type: MINUS,
+            Value: -,
+            Line: 1,
+          },
+          Operator: Token {
+            Type: MINUS,
+            Value: -,
+            Line: 1,
+          },
+          Right: InfixExpression {
+            Left: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: a,
+                Line: 1,
+              },
+              Value: a,
+            },
+            Operator: Token {
+              Type: POWER,
+              Value: **,
+              Line: 1,
+            },
+            Right: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: b,
+                Line: 1,
+              },
+              Value: b,
+            },
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserConditionalExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"a ? b : c ? d : e;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Expression: ConditionalExpression {
+          Token: Token {
+            Type: QUESTION,
+            Value: ?,
+            Line: 1,
+          },
+          Condition: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: a,
+              Line: 1,
+            },
+            Value: a,
+          },
+          Consequence: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: b,
+              Line: 1,
+            },
+            Value: b,
+          },
+          Alternative: ConditionalExpression {
+            Token: Token {
+              Type: QUESTION,
+              Value: ?,
+              Line: 1,
+            },
+            Condition: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: c,
+                Line: 1,
+              },
+              Value: c,
+            },
+            Consequence: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: d,
+                Line: 1,
+              },
+              Value: d,
+            },
+            Alternative: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: e,
+                Line: 1,
+              },
+              Value: e,
+            },
+          },
+        },
+      },
+    }`},
+		{"a ?? b ?? c;",
+			`Program {
+      ExpressionStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 1,
+        },
+        Expression: LogicalExpression {
+          Left: LogicalExpression {
+            Left: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: a,
+                Line: 1,
+              },
+              Value: a,
+            },
+            Operator: Token {
+              Type: COALESCE,
+              Value: ??,
+              Line: 1,
+            },
+            Right: IdentifierExpression {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: b,
+                Line: 1,
+              },
+              Value: b,
+            },
+          },
+          Operator: Token {
+            Type: COALESCE,
+            Value: ??,
+            Line: 1,
+          },
+          Right: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: c,
+              Line: 1,
+            },
+            Value: c,
+          },
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserDocComment(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"/// Adds one.\n///\n/// Returns a number.\ninc :: fn(x) { x + 1 }; // trailing",
+			`Program {
+      IdentifierStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: inc,
+          Line: 4,
+        },
+        Type: Token {
+          Type: CONST,
+          Value: ::,
+          Line: 4,
+        },
+        Value: FunctionExpression {
+          Token: Token {
+            Type: FUNCTION,
+            Value: fn,
+            Line: 4,
+          },
+          Parameters: IdentifierExpression {
+            Token: Token {
+              Type: IDENTIFIER,
+              Value: x,
+              Line: 4,
+            },
+            Value: x,
+          },
+          Body: BlockStatement {
+            Token: Token {
+              Type: LBRACE,
+              Value: {,
+              Line: 4,
+            },
+            Statements: ExpressionStatement {
+              Token: Token {
+                Type: IDENTIFIER,
+                Value: x,
+                Line: 4,
+              },
+              Expression: InfixExpression {
+                Left: IdentifierExpression {
+                  Token: Token {
+                    Type: IDENTIFIER,
+                    Value: x,
+                    Line: 4,
+                  },
+                  Value: x,
+                },
+                Operator: Token {
+                  Type: PLUS,
+                  Value: +,
+                  Line: 4,
+                },
+                Right: NumberExpression {
+                  Token: Token {
+                    Type: NUMBER,
+                    Value: 1,
+                    Line: 4,
+                  },
+                  Value: 1,
+                },
+              },
+            },
+          },
+        },
+        Doc: Adds one.
+
+        Returns a number.,
+      },
+    }`},
+		{"/// not attached to a variable\ny := 1 /* inline */ + 2;",
+			`Program {
+      IdentifierStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: y,
+          Line: 2,
+        },
+        Type: Token {
+          Type: VAR,
+          Value: :=,
+          Line: 2,
+        },
+        Value: InfixExpression {
+          Left: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 1,
+              Line: 2,
+            },
+            Value: 1,
+          },
+          Operator: Token {
+            Type: PLUS,
+            Value: +,
+            Line: 2,
+          },
+          Right: NumberExpression {
+            Token: Token {
+              Type: NUMBER,
+              Value: 2,
+              Line: 2,
+            },
+            Value: 2,
+          },
+        },
+      },
+    }`},
+		{"/// doc\n\n\n\na :: 1",
+			`Program {
+      IdentifierStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: a,
+          Line: 5,
+        },
+        Type: Token {
+          Type: CONST,
+          Value: ::,
+          Line: 5,
+        },
+        Value: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 1,
+            Line: 5,
+          },
+          Value: 1,
+        },
+      },
+    }`},
+		{"x := 1 /// trailing\ny :: 2",
+			`Program {
+      IdentifierStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: x,
+          Line: 1,
+        },
+        Type: Token {
+          Type: VAR,
+          Value: :=,
+          Line: 1,
+        },
+        Value: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 1,
+            Line: 1,
+          },
+          Value: 1,
+        },
+      },
+      IdentifierStatement {
+        Token: Token {
+          Type: IDENTIFIER,
+          Value: y,
+          Line: 2,
+        },
+        Type: Token {
+          Type: CONST,
+          Value: ::,
+          Line: 2,
+        },
+        Value: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 2,
+            Line: 2,
+          },
+          Value: 2,
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserLineNumbers(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{`
+    1;
+    `,
+			`Program {
+      ExpressionStatement {
+        Token: Token{
+          Type: NUMBER,
+          Value: 1,
+          Line: 2,
+        },
+        Expression: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 1,
+            Line: 2,
+          },
+          Value: 1,
+        },
+      },
+    }`},
+		{`
+
+
+    1337;
+    `,
+			`Program {
+      ExpressionStatement {
+        Token: Token{
+          Type: NUMBER,
+          Value: 1337,
+          Line: 4,
+        },
+        Expression: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 1337,
+            Line: 4,
+          },
+          Value: 1337,
+        },
+      },
+    }`},
+	}
+
+	for _, tc := range tests {
+		l := lexer.New(tc.input)
+
+		p := New(l)
+
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Error(err)
+		}
+
+		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
+			t.Errorf("wrong program generated. Expected:\n%s\ngot:\n%s", strings.ReplaceAll(tc.expectedProgram, " ", ""), strings.ReplaceAll(program.ToString(), " ", ""))
+		}
+	}
+}
+
+func TestParserGeneric(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedProgram string
+	}{
+		{"1;",
+			`Program {
+      ExpressionStatement {
+        Token: Token{
+          Type: NUMBER,
+          Value: 1,
+          Line: 1,
+        },
+        Expression: NumberExpression {
+          Token: Token {
+            Type: NUMBER,
+            Value: 1,
+            Line: 1,
+          },
+          Value: 1,
+        },
+      },
+    }`},
+		{"(1);",
+			`Program {
+      ExpressionStatement {
         Token: Token{
           Type: LPAREN,
           Value: (,
@@ -1211,11 +2870,6 @@ func TestParserGeneric(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: MODULO,
-            Value: %,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -1249,11 +2903,6 @@ func TestParserGeneric(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: PLUS,
-            Value: +,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -1287,11 +2936,6 @@ func TestParserGeneric(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: MINUS,
-            Value: -,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -1325,11 +2969,6 @@ func TestParserGeneric(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: ASTERISK,
-            Value: *,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -1363,11 +3002,6 @@ func TestParserGeneric(t *testing.T) {
           Line: 1,
         },
         Expression: InfixExpression {
-          Token: Token {
-            Type: SLASH,
-            Value: /,
-            Line: 1,
-          },
           Left: NumberExpression {
             Token: Token {
               Type: NUMBER,
@@ -1401,9 +3035,8 @@ func TestParserGeneric(t *testing.T) {
 
 		program := p.Parse()
 
-		msg, hasErrors := p.ReportParserErrors()
-		if hasErrors != nil {
-			t.Errorf(msg)
+		if err := p.Err(); err != nil {
+			t.Error(err)
 		}
 
 		if strings.ReplaceAll(program.ToString(), " ", "") != strings.ReplaceAll(tc.expectedProgram, " ", "") {
@@ -1411,3 +3044,351 @@ func TestParserGeneric(t *testing.T) {
 		}
 	}
 }
+
+func TestParserSkipsComments(t *testing.T) {
+	input := `// leading
+x := 1 + /* inline */ 2; // trailing
+/* block
+   /* nested */
+*/
+{ // inside a block
+  y := [1, /* element */ 2];
+}
+h := { /* key */ 1: 2 };
+/// documented
+f :: fn() { /// not a declaration
+  1
+};
+`
+
+	p := New(lexer.New(input))
+	program := p.Parse()
+
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(program.Statements) != 4 {
+		t.Fatalf("wrong number of statements. got=%d, want=4", len(program.Statements))
+	}
+
+	if len(program.Comments) != 9 {
+		t.Fatalf("wrong number of comments. got=%d, want=9", len(program.Comments))
+	}
+
+	if program.Comments[0].Value != "// leading" || program.Comments[3].Line != 3 {
+		t.Errorf("comments not kept in source order. got=%v", program.Comments)
+	}
+
+	declaration, ok := program.Statements[3].(*ast.IdentifierStatement)
+
+	if !ok {
+		t.Fatalf("statement is not an IdentifierStatement. got=%T", program.Statements[3])
+	}
+
+	if declaration.Doc != "documented" {
+		t.Errorf("wrong doc comment. got=%q, want=%q", declaration.Doc, "documented")
+	}
+}
+
+func TestParserSpans(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedStatement  string
+		expectedExpression string
+	}{
+		{"1 + 2 * 3;", "1 + 2 * 3;", "1 + 2 * 3"},
+		{"(1 + 2) * 3", "(1 + 2) * 3", "(1 + 2) * 3"},
+		{"((a)) && b", "((a)) && b", "((a)) && b"},
+		{"-x ** 2", "-x ** 2", "-x ** 2"},
+		{"f(a, (b))(c)", "f(a, (b))(c)", "f(a, (b))(c)"},
+		{"a[1 + 2][0]", "a[1 + 2][0]", "a[1 + 2][0]"},
+		{`"a ${b} c"`, `"a ${b} c"`, `"a ${b} c"`},
+		{"a ?? b || c", "a ?? b || c", "a ?? b || c"},
+		{"x := c ? 1 : 2;", "x := c ? 1 : 2;", "c ? 1 : 2"},
+		{"f :: fn(a, b) { a + b };", "f :: fn(a, b) { a + b };", "fn(a, b) { a + b }"},
+		{"h := {1: 2, 3: [4]}", "h := {1: 2, 3: [4]}", "{1: 2, 3: [4]}"},
+		{"if x { 1 } else if y { 2 } else { 3 }", "if x { 1 } else if y { 2 } else { 3 }", "if x { 1 } else if y { 2 } else { 3 }"},
+		{"return 1 + 2;", "return 1 + 2;", "1 + 2"},
+		{"a[0] += 1;", "a[0] += 1;", "1"},
+		{"while x { break; }", "while x { break; }", ""},
+		{"for i := 0; i < 3; i += 1 { }", "for i := 0; i < 3; i += 1 { }", ""},
+		{"{ x; }", "{ x; }", ""},
+		{"  true  ", "true", "true"},
+	}
+
+	for _, tc := range tests {
+		p := New(lexer.New(tc.input))
+		program := p.Parse()
+
+		if err := p.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		statement := program.Statements[0]
+		span := statement.Span()
+
+		if got := tc.input[span.Start.Offset:span.End.Offset]; got != tc.expectedStatement {
+			t.Errorf("%q: wrong statement span. got=%q, want=%q", tc.input, got, tc.expectedStatement)
+		}
+
+		var expression ast.Expression
+
+		switch statement := statement.(type) {
+		case *ast.ExpressionStatement:
+			expression = statement.Expression
+		case *ast.IdentifierStatement:
+			expression = statement.Value
+		case *ast.ReturnStatement:
+			expression = statement.Value
+		case *ast.IndexAssignStatement:
+			expression = statement.Value
+		default:
+			continue
+		}
+
+		span = expression.Span()
+
+		if got := tc.input[span.Start.Offset:span.End.Offset]; got != tc.expectedExpression {
+			t.Errorf("%q: wrong expression span. got=%q, want=%q", tc.input, got, tc.expectedExpression)
+		}
+	}
+}
+
+func TestParserSpanPositions(t *testing.T) {
+	input := "x := [\n  1,\n  öö + 2\n];\ny := 3;"
+
+	p := New(lexer.NewFile("main.zip", input))
+	program := p.Parse()
+
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		node          ast.Node
+		expectedStart token.Position
+		expectedEnd   token.Position
+	}{
+		{program.Statements[0], token.Position{File: "main.zip", Line: 1, Column: 1, Offset: 0}, token.Position{File: "main.zip", Line: 4, Column: 3, Offset: 25}},
+		{program.Statements[0].(*ast.IdentifierStatement).Value.(*ast.ArrayExpression).Elements[1], token.Position{File: "main.zip", Line: 3, Column: 3, Offset: 14}, token.Position{File: "main.zip", Line: 3, Column: 9, Offset: 22}},
+		{program, token.Position{File: "main.zip", Line: 1, Column: 1, Offset: 0}, token.Position{File: "main.zip", Line: 5, Column: 8, Offset: 33}},
+	}
+
+	for i, tc := range tests {
+		span := tc.node.Span()
+
+		if span.Start != tc.expectedStart {
+			t.Errorf("tests [%d] - wrong start. got=%+v, want=%+v", i, span.Start, tc.expectedStart)
+		}
+
+		if span.End != tc.expectedEnd {
+			t.Errorf("tests [%d] - wrong end. got=%+v, want=%+v", i, span.End, tc.expectedEnd)
+		}
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{`"open`, []string{
+			"1:1: illegal token: unterminated string",
+		}},
+		{"x := \n\"bad \\q\";", []string{
+			"2:1: illegal token: invalid escape sequence: \\q",
+		}},
+		{"x := 0x;", []string{
+			"1:6: illegal token: invalid number literal 0x: missing hexadecimal digits",
+		}},
+		{"1__0;", []string{
+			"1:1: illegal token: invalid number literal 1__0: _ must separate digits",
+		}},
+		{"1e99999;", []string{
+			`1:1: could not parse "1e99999" as float`,
+		}},
+		{"\"a ${\n\n1 +\n}\"", []string{
+			"4:1: no prefix parse function for STRING_END found",
+		}},
+		{"\"a ${x", []string{
+			"1:7: expected next token to be: STRING_END, got EOF instead",
+		}},
+		{"a ? b;", []string{
+			"1:6: expected next token to be: COLON, got SEMICOLON instead",
+		}},
+		{"a ? b ?? c;", []string{
+			"1:11: expected next token to be: COLON, got SEMICOLON instead",
+		}},
+		{"x := 1;\n  y := );\nz := 3;", []string{
+			"2:8: no prefix parse function for RPAREN found",
+		}},
+		{"[1, 2", []string{
+			"1:6: expected next token to be: RBRACKET, got EOF instead",
+		}},
+		{"if x { 1 } else", []string{
+			"1:16: expected next token to be: LBRACE, got EOF instead",
+		}},
+		{"h := {1 2}", []string{
+			"1:9: expected next token to be: COLON, got NUMBER instead",
+		}},
+		{"x := ;\ny := );\nz := 1;", []string{
+			"1:6: no prefix parse function for SEMICOLON found",
+			"2:6: no prefix parse function for RPAREN found",
+		}},
+		{"f :: fn() {\n  a := ;\n  b := [1 2];\n  return a\n}\nh := {1: };", []string{
+			"2:8: no prefix parse function for SEMICOLON found",
+			"3:11: expected next token to be: RBRACKET, got NUMBER instead",
+			"6:10: no prefix parse function for RBRACE found",
+		}},
+		{"while x { y := ( }\nz := *;", []string{
+			"1:18: no prefix parse function for RBRACE found",
+			"2:6: no prefix parse function for ASTERISK found",
+		}},
+		{"if x { y := 1;", []string{
+			"1:15: expected next token to be: RBRACE, got EOF instead",
+		}},
+		{"f(1 2)\ng(3 4)", []string{
+			"1:5: expected next token to be: RPAREN, got NUMBER instead",
+			"2:5: expected next token to be: RPAREN, got NUMBER instead",
+		}},
+		{"fn(a b) { 1 }\nx := ;", []string{
+			"1:6: expected next token to be: RPAREN, got IDENTIFIER instead",
+			"2:6: no prefix parse function for SEMICOLON found",
+		}},
+		{"[1, 2\nx := ;", []string{
+			"2:1: expected next token to be: RBRACKET, got IDENTIFIER instead",
+			"2:6: no prefix parse function for SEMICOLON found",
+		}},
+		{"h := {\n \"a\": 1\n \"b\": 2,\n \"c\": 3\n}", []string{
+			"3:2: expected next token to be: COMMA, got STRING instead",
+		}},
+		{"f(1 2,\n 3,\n 4)", []string{
+			"1:5: expected next token to be: RPAREN, got NUMBER instead",
+		}},
+		{"x := ) y := 2 z := *", []string{
+			"1:6: no prefix parse function for RPAREN found",
+			"1:20: no prefix parse function for ASTERISK found",
+		}},
+		{"x := 1 +* fn() {\n  1\n}\ny := )", []string{
+			"1:9: no prefix parse function for ASTERISK found",
+			"4:6: no prefix parse function for RPAREN found",
+		}},
+		{"x := 12abc", []string{"1:6: illegal token: invalid number literal 12abc: invalid character 'a' in decimal literal"}},
+		{"x := 1; y := [x, 2];", []string{}},
+		{"while x { };\nfor ;; { };\nwhile y { }", []string{}},
+		{"{ x };\n{ { y }; };\n{}", []string{}},
+	}
+
+	for _, tc := range tests {
+		p := New(lexer.New(tc.input))
+		p.Parse()
+
+		errors := p.Errors()
+
+		if len(errors) != len(tc.expectedErrors) {
+			t.Errorf("%q: wrong number of errors. got=%d (%v), want=%d", tc.input, len(errors), errors, len(tc.expectedErrors))
+			continue
+		}
+
+		for i, err := range errors {
+			if err.Error() != tc.expectedErrors[i] {
+				t.Errorf("%q: wrong error [%d]. got=%q, want=%q", tc.input, i, err.Error(), tc.expectedErrors[i])
+			}
+		}
+
+		if (p.Err() == nil) != (len(tc.expectedErrors) == 0) {
+			t.Errorf("%q: Err() does not agree with Errors(). got=%v", tc.input, p.Err())
+		}
+	}
+}
+
+func TestParserBadNodes(t *testing.T) {
+	input := "x := ;\nwhile x 1;\ny := [1, (2 + ];\nz := 3;"
+
+	p := New(lexer.New(input))
+	program := p.Parse()
+
+	if len(p.Errors()) != 3 {
+		t.Fatalf("wrong number of errors. got=%d (%v)", len(p.Errors()), p.Errors())
+	}
+
+	if len(program.Statements) != 4 {
+		t.Fatalf("wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	if _, ok := program.Statements[0].(*ast.IdentifierStatement).Value.(*ast.BadExpression); !ok {
+		t.Errorf("statement 0 value is not a BadExpression. got=%T", program.Statements[0].(*ast.IdentifierStatement).Value)
+	}
+
+	if _, ok := program.Statements[1].(*ast.BadStatement); !ok {
+		t.Errorf("statement 1 is not a BadStatement. got=%T", program.Statements[1])
+	}
+
+	if _, ok := program.Statements[2].(*ast.IdentifierStatement).Value.(*ast.BadExpression); !ok {
+		t.Errorf("statement 2 value is not a BadExpression. got=%T", program.Statements[2].(*ast.IdentifierStatement).Value)
+	}
+
+	if _, ok := program.Statements[3].(*ast.IdentifierStatement).Value.(*ast.NumberExpression); !ok {
+		t.Errorf("statement 3 value is not a NumberExpression. got=%T", program.Statements[3].(*ast.IdentifierStatement).Value)
+	}
+
+	// every node must be printable, which fails on a nil node
+	program.ToString()
+}
+
+func TestParserErrorDetails(t *testing.T) {
+	input := "x := 1;\na ? b;\ny := ;"
+
+	p := New(lexer.NewFile("main.zip", input))
+	p.Parse()
+
+	expected := []ParseError{
+		{
+			Code:     UnexpectedToken,
+			Message:  "expected next token to be: COLON, got SEMICOLON instead",
+			Start:    token.Position{File: "main.zip", Line: 2, Column: 6, Offset: 13},
+			End:      token.Position{File: "main.zip", Line: 2, Column: 7, Offset: 14},
+			Expected: token.COLON,
+			Found:    token.SEMICOLON,
+		},
+		{
+			Code:    NoPrefix,
+			Message: "no prefix parse function for SEMICOLON found",
+			Start:   token.Position{File: "main.zip", Line: 3, Column: 6, Offset: 20},
+			End:     token.Position{File: "main.zip", Line: 3, Column: 7, Offset: 21},
+			Found:   token.SEMICOLON,
+		},
+	}
+
+	errors := p.Errors()
+
+	if len(errors) != len(expected) {
+		t.Fatalf("wrong number of errors. got=%d (%v), want=%d", len(errors), errors, len(expected))
+	}
+
+	for i := range expected {
+		if errors[i] != expected[i] {
+			t.Errorf("wrong error [%d].\ngot= %#v\nwant=%#v", i, errors[i], expected[i])
+		}
+	}
+
+	err := p.Err()
+
+	list, ok := err.(ErrorList)
+
+	if !ok {
+		t.Fatalf("Err() is not an ErrorList. got=%T", err)
+	}
+
+	if len(list) != len(expected) {
+		t.Errorf("wrong number of errors in ErrorList. got=%d, want=%d", len(list), len(expected))
+	}
+
+	expectedMessage := "main.zip:2:6: expected next token to be: COLON, got SEMICOLON instead\n" +
+		"main.zip:3:6: no prefix parse function for SEMICOLON found"
+
+	if err.Error() != expectedMessage {
+		t.Errorf("wrong error message. got=%q, want=%q", err.Error(), expectedMessage)
+	}
+}
@@ -6,9 +6,23 @@ import (
 )
 
 type Token struct {
-	Type  TokenType
-	Value string
-	Line  int
+	Type      TokenType
+	Value     string
+	Line      int
+	Column    int    // 1-based, counted in runes
+	File      string // empty for source that did not come from a file
+	Start     int    // byte offset of the first byte of the token
+	End       int    // byte offset just past the last byte of the token
+	EndLine   int    // line of End; differs from Line for multi-line tokens
+	EndColumn int    // column of End
+}
+
+// Position is a point in the source, such as either end of a token.
+type Position struct {
+	File   string
+	Line   int
+	Column int // 1-based, counted in runes
+	Offset int // byte offset
 }
 
 func New(t TokenType, v string, l int) Token {
@@ -19,6 +33,16 @@ func New(t TokenType, v string, l int) Token {
 	}
 }
 
+// Pos returns the position of the first byte of the token.
+func (t *Token) Pos() Position {
+	return Position{File: t.File, Line: t.Line, Column: t.Column, Offset: t.Start}
+}
+
+// EndPos returns the position just past the last byte of the token.
+func (t *Token) EndPos() Position {
+	return Position{File: t.File, Line: t.EndLine, Column: t.EndColumn, Offset: t.End}
+}
+
 func (t *Token) ToString() string {
 	var out bytes.Buffer
 
@@ -40,19 +64,28 @@ func (t *Token) ToString() string {
 type TokenType string
 
 const (
-	ILLEGAL = "ILLEGAL"
-	EOF     = "EOF"
-	COMMENT = "COMMENT"
+	ILLEGAL     = "ILLEGAL"
+	EOF         = "EOF"
+	COMMENT     = "COMMENT"
+	DOC_COMMENT = "DOC_COMMENT"
 
 	// Identifiers + literals
 	IDENTIFIER = "IDENTIFIER"
 	NUMBER     = "NUMBER"
+	FLOAT      = "FLOAT"
 	STRING     = "STRING"
 
+	// Interpolated strings: "a${x}b${y}c" is STRING_START(a), x,
+	// STRING_MIDDLE(b), y, STRING_END(c)
+	STRING_START  = "STRING_START"
+	STRING_MIDDLE = "STRING_MIDDLE"
+	STRING_END    = "STRING_END"
+
 	// Operators
 	PLUS     = "PLUS"
 	MINUS    = "MINUS"
 	ASTERISK = "ASTERISK"
+	POWER    = "POWER"
 	MODULO   = "MODULO"
 	SLASH    = "SLASH"
 	ASSIGN   = "ASSIGN"
@@ -60,15 +93,39 @@ const (
 	CONST    = "CONST"
 	VAR      = "VAR"
 
+	// Compound assignments
+	PLUS_ASSIGN     = "PLUS_ASSIGN"
+	MINUS_ASSIGN    = "MINUS_ASSIGN"
+	ASTERISK_ASSIGN = "ASTERISK_ASSIGN"
+	SLASH_ASSIGN    = "SLASH_ASSIGN"
+	MODULO_ASSIGN   = "MODULO_ASSIGN"
+
+	// Bitwise operators
+	BIT_AND     = "BIT_AND"
+	BIT_OR      = "BIT_OR"
+	BIT_XOR     = "BIT_XOR"
+	BIT_NOT     = "BIT_NOT"
+	SHIFT_LEFT  = "SHIFT_LEFT"
+	SHIFT_RIGHT = "SHIFT_RIGHT"
+
+	// Logical operators
+	AND      = "AND"
+	OR       = "OR"
+	COALESCE = "COALESCE"
+
 	// Comparisons
 	LT     = "LT"
 	GT     = "GT"
+	LT_EQ  = "LT_EQ"
+	GT_EQ  = "GT_EQ"
 	EQ     = "EQ"
 	NOT_EQ = "NOT_EQ"
 
 	// Delimiters
 	COMMA     = "COMMA"
 	SEMICOLON = "SEMICOLON"
+	COLON     = "COLON"
+	QUESTION  = "QUESTION"
 
 	LPAREN   = "LPAREN"
 	RPAREN   = "RPAREN"
@@ -82,13 +139,25 @@ const (
 	FALSE    = "FALSE"
 	RETURN   = "RETURN"
 	FUNCTION = "FUNCTION"
+	IF       = "IF"
+	ELSE     = "ELSE"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
 )
 
 var keywords = map[string]TokenType{
-	"true":   TRUE,
-	"false":  FALSE,
-	"return": RETURN,
-	"fn":     FUNCTION,
+	"true":     TRUE,
+	"false":    FALSE,
+	"return":   RETURN,
+	"fn":       FUNCTION,
+	"if":       IF,
+	"else":     ELSE,
+	"while":    WHILE,
+	"for":      FOR,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
 func LookupIdentifier(identifier string) TokenType {
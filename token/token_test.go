@@ -16,6 +16,13 @@ func TestTokenLookupIdentifier(t *testing.T) {
     {"return", RETURN},
     {"false", FALSE},
     {"true", TRUE},
+    {"if", IF},
+    {"else", ELSE},
+    {"iffy", IDENTIFIER},
+    {"while", WHILE},
+    {"for", FOR},
+    {"break", BREAK},
+    {"continue", CONTINUE},
   }
 
   for _, tc := range tests {